@@ -0,0 +1,163 @@
+// Package analysis estimates how a consistent hashing ring balances a
+// workload, and how many keys a membership change moves, e.g. to estimate
+// the cache misses of adding a node before doing it in production:
+//
+//	keys := analysis.Zipf(100000, 1.1)
+//	report := analysis.Analyze(ring, keys)
+//	movement := analysis.Moved(ring, ring.AddNode("10.0.0.4:11211"), keys)
+//
+// A workload is a sample of keys, each weighted by how often it is used.
+// Use real keys where possible: synthetic ones only show the balance of
+// the ring, not the one of the key space. Keys are resolved with
+// hashring.HashRing.Owner, so analysing a live ring neither notifies its
+// observer nor advances its hot key selectors; hot keys count for the
+// node owning them.
+package analysis
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/serialx/hashring"
+)
+
+// Key is a key of a workload with its weight, e.g. its number of requests.
+type Key struct {
+	Key    string
+	Weight float64
+}
+
+// Uniform returns a workload of keys with a weight of 1.
+func Uniform(keys []string) []Key {
+	workload := make([]Key, len(keys))
+	for i, key := range keys {
+		workload[i] = Key{Key: key, Weight: 1}
+	}
+	return workload
+}
+
+// Zipf returns a synthetic workload of n keys named key-0 to key-(n-1),
+// where the weight of the key of rank r is 1/(r+1)^s. A larger s makes
+// the first keys hotter; s = 0 is a uniform workload.
+func Zipf(n int, s float64) []Key {
+	workload := make([]Key, n)
+	for i := range workload {
+		workload[i] = Key{Key: "key-" + strconv.Itoa(i), Weight: math.Pow(float64(i+1), -s)}
+	}
+	return workload
+}
+
+// Report is the load of the nodes of a ring under a workload.
+type Report struct {
+	// Load is the sum of the weights of the keys of every node of the
+	// ring, including the nodes without keys.
+	Load map[string]float64
+	// Total is the sum of the weights of all keys.
+	Total float64
+	// Mean and Max are the mean and maximum load of a node.
+	Mean, Max float64
+	// Imbalance is the maximum ratio of the load of a node to its fair
+	// share of Total, in proportion to its weight. For a ring with equal
+	// weights it is Max/Mean; 1 is a perfect balance.
+	Imbalance float64
+
+	loads []float64
+}
+
+// Analyze returns the load of the nodes of ring under the workload keys.
+func Analyze(ring *hashring.HashRing, keys []Key) Report {
+	weights := ring.Weights()
+	report := Report{Load: make(map[string]float64, len(weights))}
+	if len(weights) == 0 {
+		return report
+	}
+	for node := range weights {
+		report.Load[node] = 0
+	}
+
+	for i, node := range owners(ring, keys) {
+		report.Load[node] += keys[i].Weight
+		report.Total += keys[i].Weight
+	}
+
+	totalWeight := 0.0
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+	for node, load := range report.Load {
+		report.Max = max(report.Max, load)
+		if report.Total > 0 {
+			share := report.Total * weights[node] / totalWeight
+			report.Imbalance = max(report.Imbalance, load/share)
+		}
+	}
+	report.Mean = report.Total / float64(len(report.Load))
+	report.loads = slices.Sorted(maps.Values(report.Load))
+	return report
+}
+
+// Percentile returns the load a percentage p in [0, 100] of the nodes
+// don't exceed, using the nearest rank method.
+func (r Report) Percentile(p float64) float64 {
+	if len(r.loads) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(r.loads))))
+	return r.loads[min(max(rank, 1), len(r.loads))-1]
+}
+
+// Flow is a move of keys from one node to another.
+type Flow struct {
+	From, To string
+}
+
+// Movement is the part of a workload moved by a membership change.
+type Movement struct {
+	// Keys is the number of keys moved.
+	Keys int
+	// Weight is the sum of the weights of the keys moved.
+	Weight float64
+	// Fraction is the share of the weight of all keys moved, which is the
+	// expected share of cache misses right after the change.
+	Fraction float64
+	// Flows is the weight of the keys moved between every pair of nodes.
+	Flows map[Flow]float64
+}
+
+// Moved returns the part of the workload keys which moves from oldRing to
+// newRing, e.g. for a proposed change:
+//
+//	analysis.Moved(ring, ring.RemoveNode(node), keys)
+//	analysis.Moved(ring, ring.UpdateWeightedNode(node, 2), keys)
+//
+// The keys of an empty ring belong to the node "".
+func Moved(oldRing, newRing *hashring.HashRing, keys []Key) Movement {
+	movement := Movement{Flows: make(map[Flow]float64)}
+	oldNodes := owners(oldRing, keys)
+	newNodes := owners(newRing, keys)
+
+	total := 0.0
+	for i, key := range keys {
+		total += key.Weight
+		if oldNodes[i] != newNodes[i] {
+			movement.Keys++
+			movement.Weight += key.Weight
+			movement.Flows[Flow{From: oldNodes[i], To: newNodes[i]}] += key.Weight
+		}
+	}
+	if total > 0 {
+		movement.Fraction = movement.Weight / total
+	}
+	return movement
+}
+
+// owners returns the owners of keys, or empty strings if ring is empty.
+func owners(ring *hashring.HashRing, keys []Key) []string {
+	nodes := make([]string, len(keys))
+	for i, key := range keys {
+		nodes[i], _ = ring.Owner(key.Key)
+	}
+	return nodes
+}
@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/serialx/hashring"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZipf(t *testing.T) {
+	keys := Zipf(3, 1)
+	assert.Equal(t, []Key{{"key-0", 1}, {"key-1", 0.5}, {"key-2", 1.0 / 3}}, keys)
+	assert.Equal(t, []Key{{"a", 1}, {"b", 1}}, Uniform([]string{"a", "b"}))
+}
+
+func TestAnalyze(t *testing.T) {
+	ring := hashring.New([]string{"a", "b", "c", "d"}, hashring.WithReplicas(100))
+	keys := Zipf(10000, 0)
+
+	report := Analyze(ring, keys)
+	assert.Len(t, report.Load, 4)
+	assert.Equal(t, 10000.0, report.Total)
+	assert.Equal(t, 2500.0, report.Mean)
+	assert.Equal(t, report.Max/report.Mean, report.Imbalance)
+	assert.Less(t, report.Imbalance, 1.2)
+	assert.Equal(t, report.Max, report.Percentile(100))
+	assert.LessOrEqual(t, report.Percentile(0), report.Percentile(50))
+	assert.LessOrEqual(t, report.Percentile(50), report.Max)
+
+	// a single hot key overloads its node
+	skewed := Analyze(ring, Zipf(10000, 1.5))
+	assert.Greater(t, skewed.Imbalance, report.Imbalance)
+}
+
+func TestAnalyzeWeighted(t *testing.T) {
+	ring := hashring.NewWithWeights(map[string]int{"a": 1, "b": 3}, hashring.WithReplicas(100))
+	report := Analyze(ring, Zipf(10000, 0))
+
+	assert.InDelta(t, 0.25, report.Load["a"]/report.Total, 0.05)
+	assert.Less(t, report.Imbalance, 1.2)
+	assert.Greater(t, report.Max/report.Mean, 1.4)
+}
+
+func TestAnalyzeEmpty(t *testing.T) {
+	report := Analyze(hashring.New(nil), Zipf(10, 1))
+	assert.Empty(t, report.Load)
+	assert.Equal(t, 0.0, report.Percentile(50))
+
+	report = Analyze(hashring.New([]string{"a", "b"}), nil)
+	assert.Equal(t, map[string]float64{"a": 0, "b": 0}, report.Load)
+	assert.Equal(t, 0.0, report.Imbalance)
+}
+
+func TestMoved(t *testing.T) {
+	ring := hashring.New([]string{"a", "b", "c"}, hashring.WithReplicas(100))
+	keys := Zipf(10000, 0)
+
+	added := Moved(ring, ring.AddNode("d"), keys)
+	assert.InDelta(t, 0.25, added.Fraction, 0.05)
+	assert.Equal(t, float64(added.Keys), added.Weight)
+	for flow := range added.Flows {
+		assert.Equal(t, "d", flow.To)
+	}
+
+	removed := Moved(ring, ring.RemoveNode("c"), keys)
+	for flow := range removed.Flows {
+		assert.Equal(t, "c", flow.From)
+	}
+	report := Analyze(ring, keys)
+	assert.Equal(t, report.Load["c"], removed.Weight)
+
+	reweighted := Moved(ring, ring.UpdateWeightedNode("a", 2), keys)
+	for flow := range reweighted.Flows {
+		assert.Equal(t, "a", flow.To)
+	}
+
+	assert.Equal(t, 0, Moved(ring, ring, keys).Keys)
+	assert.Equal(t, 1.0, Moved(ring, hashring.New(nil), keys).Fraction)
+}
+
+type lookupCounter struct {
+	lookups int
+}
+
+func (c *lookupCounter) OnLookup(key, node string, pos int)            { c.lookups++ }
+func (c *lookupCounter) OnRebuild(oldRing, newRing *hashring.HashRing) {}
+func (c *lookupCounter) OnNodeSkipped(key, node string)                {}
+
+func TestAnalyzeLiveRing(t *testing.T) {
+	keys := Zipf(1000, 1)
+	counter := &lookupCounter{}
+	ring := hashring.New([]string{"a", "b", "c"},
+		hashring.WithReplicas(40),
+		hashring.WithObserver(counter),
+		hashring.WithHotKeys(3, hashring.RandomSelector(), "key-0", "key-1"))
+
+	assert.Equal(t, 0, Moved(ring, ring, keys).Keys)
+	report := Analyze(ring, keys)
+	owner, _ := ring.Owner("key-0")
+	assert.GreaterOrEqual(t, report.Load[owner], 1.0)
+	assert.Zero(t, counter.lookups)
+}
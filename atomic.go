@@ -0,0 +1,66 @@
+package hashring
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// AtomicRing holds a *HashRing which can be replaced while other
+// goroutines look up keys. Lookups never block; updates are serialized.
+type AtomicRing struct {
+	mu   sync.Mutex
+	ring atomic.Pointer[HashRing]
+}
+
+func NewAtomicRing(ring *HashRing) *AtomicRing {
+	r := &AtomicRing{}
+	r.ring.Store(ring)
+	return r
+}
+
+// Load returns the current ring.
+func (r *AtomicRing) Load() *HashRing {
+	return r.ring.Load()
+}
+
+// Store replaces the current ring.
+func (r *AtomicRing) Store(ring *HashRing) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ring.Store(ring)
+}
+
+// Update replaces the current ring with the one returned by update, e.g.
+//
+//	r.Update(func(ring *hashring.HashRing) *hashring.HashRing {
+//		return ring.AddNode("192.168.0.250:11212")
+//	})
+//
+// Concurrent updates are applied one after another.
+func (r *AtomicRing) Update(update func(ring *HashRing) *HashRing) (oldRing, newRing *HashRing) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	oldRing = r.ring.Load()
+	newRing = update(oldRing)
+	r.ring.Store(newRing)
+	return oldRing, newRing
+}
+
+// UpdateWithFloatWeights replaces the current ring with one with the given
+// weights, keeping its configuration. The ring is only rebuilt if the
+// weights differ from the current ones.
+func (r *AtomicRing) UpdateWithFloatWeights(weights map[string]float64) (oldRing, newRing *HashRing) {
+	weights = maps.Clone(weights)
+	return r.Update(func(ring *HashRing) *HashRing {
+		return ring.withWeights(weights)
+	})
+}
+
+func (r *AtomicRing) GetNode(stringKey string) (node string, ok bool) {
+	return r.Load().GetNode(stringKey)
+}
+
+func (r *AtomicRing) GetNodes(stringKey string, size int) (nodes []string, ok bool) {
+	return r.Load().GetNodes(stringKey, size)
+}
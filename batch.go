@@ -0,0 +1,117 @@
+package hashring
+
+import (
+	"cmp"
+	"slices"
+)
+
+// GetNodesBatch returns the node of every key, like calling GetNode for each
+// of them. Instead of a binary search per key, it sorts the positions of
+// the keys and resolves their owners in a single merged pass over the
+// ring. The positions of the built-in hash keys are sorted as plain
+// integers, which is what makes the pass pay off; keys of other HashKey
+// types are searched one by one. Hashing the keys still costs the same.
+func (h *HashRing) GetNodesBatch(stringKeys []string) (nodes []string, ok bool) {
+	if len(h.ring) == 0 {
+		return nil, false
+	}
+
+	nodes = make([]string, len(stringKeys))
+	positions := make([]int, len(stringKeys))
+	pending := make([]pendingKey, 0, len(stringKeys))
+	for i, stringKey := range stringKeys {
+		if node, ok := h.pinned(stringKey); ok {
+			nodes[i], positions[i] = node, -1
+			continue
+		}
+		if node, ok := h.hotNode(stringKey); ok {
+			nodes[i], positions[i] = node, -1
+			continue
+		}
+		key := h.GenKey(stringKey)
+		if h.sortKeys != nil {
+			if sortKey, ok := sortKeyOf(key); ok {
+				pending = append(pending, pendingKey{sortKey, i})
+				continue
+			}
+		}
+		positions[i] = h.search(key)
+		nodes[i] = h.ring[h.sortedKeys[positions[i]]]
+	}
+	slices.SortFunc(pending, func(a, b pendingKey) int {
+		return a.key.compare(b.key)
+	})
+
+	pos := 0
+	for _, p := range pending {
+		for pos < len(h.sortKeys) && p.key.compare(h.sortKeys[pos]) >= 0 {
+			pos++
+		}
+		// Wrap the search, should return First node
+		positions[p.i] = pos % len(h.sortedKeys)
+		nodes[p.i] = h.ring[h.sortedKeys[positions[p.i]]]
+	}
+
+	if h.observer != nil {
+		for i, stringKey := range stringKeys {
+			h.observer.OnLookup(stringKey, nodes[i], positions[i])
+		}
+	}
+	return nodes, true
+}
+
+// sortKey is the position of a built-in hash key as plain integers, which
+// compare without the dynamic calls of HashKey.Less.
+type sortKey struct {
+	high, low int64
+}
+
+func sortKeyOf(key HashKey) (sortKey, bool) {
+	switch key := key.(type) {
+	case *Int64PairHashKey:
+		return sortKey{key.High, key.Low}, true
+	case Uint32HashKey:
+		return sortKey{high: int64(key)}, true
+	}
+	return sortKey{}, false
+}
+
+// sortKeysOf returns the sort keys of keys, or nil if any of them has no
+// sort key.
+func sortKeysOf(keys []HashKey) []sortKey {
+	sortKeys := make([]sortKey, len(keys))
+	for i, key := range keys {
+		sortKey, ok := sortKeyOf(key)
+		if !ok {
+			return nil
+		}
+		sortKeys[i] = sortKey
+	}
+	return sortKeys
+}
+
+func (k sortKey) compare(other sortKey) int {
+	if c := cmp.Compare(k.high, other.high); c != 0 {
+		return c
+	}
+	return cmp.Compare(k.low, other.low)
+}
+
+// pendingKey is the sort key of the i-th key of a batch.
+type pendingKey struct {
+	key sortKey
+	i   int
+}
+
+// GroupByNode groups keys by the node owning them, keeping their order.
+func (h *HashRing) GroupByNode(stringKeys []string) map[string][]string {
+	groups := make(map[string][]string)
+	nodes, ok := h.GetNodesBatch(stringKeys)
+	if !ok {
+		return groups
+	}
+	for i, node := range nodes {
+		groups[node] = append(groups[node], stringKeys[i])
+	}
+	return groups
+}
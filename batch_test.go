@@ -0,0 +1,69 @@
+package hashring
+
+import (
+	"fmt"
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodesBatch(t *testing.T) {
+	overrides := NewOverrides()
+	overrides.Pin("key7", "c")
+	ring := New([]string{"a", "b", "c", "d"}, WithReplicas(20), WithOverrides(overrides))
+
+	keys := make([]string, 0)
+	for i := 0; i < 1000; i++ {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+	}
+	keys = append(keys, "key1", "")
+
+	nodes, ok := ring.GetNodesBatch(keys)
+	assert.True(t, ok)
+	for i, key := range keys {
+		expected, _ := ring.GetNode(key)
+		assert.Equal(t, expected, nodes[i], key)
+	}
+	assert.Equal(t, "c", nodes[7])
+
+	nodes, ok = New(nil).GetNodesBatch(keys)
+	assert.False(t, ok)
+	assert.Nil(t, nodes)
+}
+
+func TestGetNodesBatchHashKeys(t *testing.T) {
+	keys := make([]string, 0)
+	for i := 0; i < 1000; i++ {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+	}
+
+	for name, hashFunc := range map[string]HashFunc{
+		"uint32": func(key []byte) HashKey {
+			return Uint32HashKey(crc32.ChecksumIEEE(key))
+		},
+		"other": func(key []byte) HashKey {
+			return stringHashKey(key)
+		},
+	} {
+		ring := NewWithHash([]string{"a", "b", "c", "d"}, hashFunc, WithReplicas(20))
+		nodes, ok := ring.GetNodesBatch(keys)
+		assert.True(t, ok)
+		for i, key := range keys {
+			expected, _ := ring.GetNode(key)
+			assert.Equal(t, expected, nodes[i], name, key)
+		}
+	}
+}
+
+func TestGroupByNode(t *testing.T) {
+	ring := New([]string{"a", "b", "c"})
+	keys := []string{"test", "test1", "test2", "test3", "test4", "test5", "aaaa", "bbbb"}
+
+	assert.Equal(t, map[string][]string{
+		"a": {"test", "test4", "bbbb"},
+		"b": {"test1", "test2"},
+		"c": {"test3", "test5", "aaaa"},
+	}, ring.GroupByNode(keys))
+	assert.Empty(t, New(nil).GroupByNode(keys))
+}
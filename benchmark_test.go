@@ -1,6 +1,9 @@
 package hashring
 
-import "testing"
+import (
+	"strconv"
+	"testing"
+)
 
 func BenchmarkNew(b *testing.B) {
 	nodes := []string{"a", "b", "c", "d", "e", "f", "g"}
@@ -57,3 +60,33 @@ func BenchmarkHashesSingle(b *testing.B) {
 		ring.GetNode(o.key)
 	}
 }
+
+func benchmarkKeys(n int) []string {
+	keys := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		keys = append(keys, "key"+strconv.Itoa(i))
+	}
+	return keys
+}
+
+func BenchmarkGetNodeLoop(b *testing.B) {
+	ring := New(generateNodes(100), WithReplicas(160))
+	keys := benchmarkKeys(5000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, key := range keys {
+			ring.GetNode(key)
+		}
+	}
+}
+
+// BenchmarkGetNodesBatch runs the keys of BenchmarkGetNodeLoop through
+// GetNodesBatch, to compare its merged pass with the binary searches.
+func BenchmarkGetNodesBatch(b *testing.B) {
+	ring := New(generateNodes(100), WithReplicas(160))
+	keys := benchmarkKeys(5000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ring.GetNodesBatch(keys)
+	}
+}
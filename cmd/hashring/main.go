@@ -0,0 +1,306 @@
+// Command hashring answers questions about a consistent hashing ring,
+// e.g. which node owns a key or how many keys move if a node is added.
+//
+// Usage:
+//
+//	hashring [flags] lookup [-n replicas] key...
+//	hashring [flags] dist
+//	hashring [flags] simulate [-add nodes] [-remove nodes] [-keys n]
+//	hashring [flags] diff config
+//
+// The ring is loaded from a membership file given by -config, in any
+// format accepted by hashring.ParseMembership, or from a comma separated
+// list of nodes given by -nodes, where each node may have a weight:
+//
+//	hashring -nodes 10.0.0.1:11211,10.0.0.2:11211=2 lookup user:42
+//
+// The commands are:
+//
+//	lookup    print the nodes of the keys, the first one being the owner
+//	dist      print the weight and the share of the hash space of the nodes
+//	simulate  print the percentage of keys moved by adding or removing nodes
+//	diff      compare the ring with the one of another membership file
+//
+// simulate and diff estimate the moved keys from a sample of -keys keys.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/serialx/hashring"
+	"github.com/serialx/hashring/analysis"
+)
+
+const usage = `usage: hashring [flags] command [args]
+
+commands:
+  lookup [-n replicas] key...                     print the nodes of keys
+  dist                                            print the ownership of nodes
+  simulate [-add nodes] [-remove nodes] [-keys n] print the keys moved by a change
+  diff [-keys n] config                           compare with another config
+
+flags:
+`
+
+// errUsage is returned for invalid command lines, after printing the usage.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	err := run(os.Args[1:], os.Stdout, os.Stderr)
+	if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hashring:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("hashring", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	config := flags.String("config", "", "membership `file`, as JSON or node [weight] lines")
+	nodes := flags.String("nodes", "", "comma separated `nodes`, each optionally followed by =weight")
+	replicas := flags.Int("replicas", 1, "virtual points per unit of weight")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+
+	weights, err := loadWeights(*config, *nodes)
+	if err != nil {
+		return err
+	}
+	c := &command{
+		name:    flags.Arg(0),
+		stdout:  stdout,
+		stderr:  stderr,
+		weights: weights,
+		opts:    []hashring.Option{hashring.WithReplicas(*replicas)},
+	}
+	switch c.name {
+	case "lookup":
+		return c.lookup(flags.Args()[1:])
+	case "dist":
+		return c.dist(flags.Args()[1:])
+	case "simulate":
+		return c.simulate(flags.Args()[1:])
+	case "diff":
+		return c.diff(flags.Args()[1:])
+	}
+	fmt.Fprintf(stderr, "hashring: unknown command %q\n", c.name)
+	flags.Usage()
+	return errUsage
+}
+
+type command struct {
+	name    string
+	stdout  io.Writer
+	stderr  io.Writer
+	weights map[string]float64
+	opts    []hashring.Option
+}
+
+func (c *command) ring(weights map[string]float64) *hashring.HashRing {
+	return hashring.NewWithFloatWeights(weights, c.opts...)
+}
+
+func (c *command) flags(usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: hashring [flags] %s %s\n", c.name, usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+func (c *command) lookup(args []string) error {
+	flags := c.flags("[-n replicas] key...")
+	n := flags.Int("n", 1, "number of `replicas` to print")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 || *n < 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	ring := c.ring(c.weights)
+	if *n > ring.Size() {
+		return fmt.Errorf("can't look up %d replicas in a ring of %d nodes", *n, ring.Size())
+	}
+	w := tabwriter.NewWriter(c.stdout, 0, 8, 2, ' ', 0)
+	for _, key := range flags.Args() {
+		nodes, _ := ring.GetNodes(key, *n)
+		fmt.Fprintf(w, "%s\t%s\n", key, strings.Join(nodes, "\t"))
+	}
+	return w.Flush()
+}
+
+func (c *command) dist(args []string) error {
+	flags := c.flags("")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return errUsage
+	}
+
+	ownership := c.ring(c.weights).Ownership()
+	w := tabwriter.NewWriter(c.stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "node\tweight\townership\t")
+	for _, node := range slices.Sorted(maps.Keys(c.weights)) {
+		fmt.Fprintf(w, "%s\t%s\t%.2f%%\t\n", node, formatWeight(c.weights[node]), ownership[node]*100)
+	}
+	return w.Flush()
+}
+
+func (c *command) simulate(args []string) error {
+	flags := c.flags("[-add nodes] [-remove nodes] [-keys n]")
+	add := flags.String("add", "", "comma separated `nodes` to add or reweight, each optionally followed by =weight")
+	remove := flags.String("remove", "", "comma separated `nodes` to remove")
+	keys := flags.Int("keys", 100000, "number of sample keys")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || *keys < 1 || *add == "" && *remove == "" {
+		flags.Usage()
+		return errUsage
+	}
+
+	weights := maps.Clone(c.weights)
+	if *add != "" {
+		added, err := parseNodes(*add)
+		if err != nil {
+			return err
+		}
+		maps.Copy(weights, added)
+	}
+	if *remove != "" {
+		for _, node := range strings.Split(*remove, ",") {
+			if _, ok := weights[node]; !ok {
+				return fmt.Errorf("can't remove %q: %w", node, hashring.ErrNodeNotFound)
+			}
+			delete(weights, node)
+		}
+	}
+	if len(weights) == 0 {
+		return errors.New("can't remove all nodes")
+	}
+	return c.compare(weights, *keys)
+}
+
+func (c *command) diff(args []string) error {
+	flags := c.flags("[-keys n] config")
+	keys := flags.Int("keys", 100000, "number of sample keys")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *keys < 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	weights, err := loadWeights(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	return c.compare(weights, *keys)
+}
+
+// compare prints the membership changes from the current weights to
+// weights, and the share of the sample keys they move, by source and
+// destination node.
+func (c *command) compare(weights map[string]float64, keys int) error {
+	oldRing, newRing := c.ring(c.weights), c.ring(weights)
+
+	events := hashring.WeightEvents(c.weights, weights)
+	slices.SortFunc(events, func(a, b hashring.Event) int {
+		return strings.Compare(a.Node, b.Node)
+	})
+	for _, event := range events {
+		switch event.Type {
+		case hashring.NodeRemoved:
+			fmt.Fprintf(c.stdout, "%s %s\n", event.Type, event.Node)
+		case hashring.NodeAdded:
+			fmt.Fprintf(c.stdout, "%s %s weight %s\n", event.Type, event.Node, formatWeight(event.Weight))
+		default:
+			fmt.Fprintf(c.stdout, "%s %s weight %s -> %s\n", event.Type, event.Node,
+				formatWeight(c.weights[event.Node]), formatWeight(event.Weight))
+		}
+	}
+
+	sample := make([]string, keys)
+	for i := range sample {
+		sample[i] = "key-" + strconv.Itoa(i)
+	}
+	movement := analysis.Moved(oldRing, newRing, analysis.Uniform(sample))
+
+	fmt.Fprintf(c.stdout, "moved %.2f%% of %d keys\n", movement.Fraction*100, keys)
+	w := tabwriter.NewWriter(c.stdout, 0, 8, 2, ' ', 0)
+	flows := slices.SortedFunc(maps.Keys(movement.Flows), func(a, b analysis.Flow) int {
+		if n := strings.Compare(a.From, b.From); n != 0 {
+			return n
+		}
+		return strings.Compare(a.To, b.To)
+	})
+	for _, flow := range flows {
+		fmt.Fprintf(w, "  %s -> %s\t%.2f%%\n", flow.From, flow.To, movement.Flows[flow]*100/float64(keys))
+	}
+	return w.Flush()
+}
+
+// loadWeights loads the membership from the config file if given, or from
+// the node list otherwise.
+func loadWeights(config, nodes string) (map[string]float64, error) {
+	switch {
+	case config != "" && nodes != "":
+		return nil, errors.New("-config and -nodes are mutually exclusive")
+	case config != "":
+		data, err := os.ReadFile(config)
+		if err != nil {
+			return nil, err
+		}
+		weights, err := hashring.ParseMembership(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", config, err)
+		}
+		return weights, nil
+	case nodes != "":
+		return parseNodes(nodes)
+	}
+	return nil, errors.New("no nodes, use -config or -nodes")
+}
+
+// parseNodes parses a comma separated list of nodes, each optionally
+// followed by =weight.
+func parseNodes(list string) (map[string]float64, error) {
+	var lines []string
+	for _, node := range strings.Split(list, ",") {
+		node, weight, _ := strings.Cut(node, "=")
+		lines = append(lines, node+" "+weight)
+	}
+	return hashring.ParseMembership([]byte(strings.Join(lines, "\n")))
+}
+
+func formatWeight(weight float64) string {
+	return strconv.FormatFloat(weight, 'g', -1, 64)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/serialx/hashring"
+	"github.com/stretchr/testify/assert"
+)
+
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(args, &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestLookup(t *testing.T) {
+	ring := hashring.NewWithFloatWeights(map[string]float64{"a": 1, "b": 2, "c": 1})
+	nodes, _ := ring.GetNodes("test", 2)
+
+	out, err := runCommand(t, "-nodes", "a,b=2,c", "lookup", "-n", "2", "test")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test", nodes[0], nodes[1]}, strings.Fields(out))
+
+	_, err = runCommand(t, "-nodes", "a,b", "lookup", "-n", "3", "test")
+	assert.ErrorContains(t, err, "3 replicas in a ring of 2 nodes")
+	_, err = runCommand(t, "-nodes", "a,b", "lookup")
+	assert.ErrorIs(t, err, errUsage)
+}
+
+func TestDist(t *testing.T) {
+	out, err := runCommand(t, "-nodes", "a,b=3", "-replicas", "100", "dist")
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, []string{"node", "weight", "ownership"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"a", "1"}, strings.Fields(lines[1])[:2])
+	assert.Equal(t, []string{"b", "3"}, strings.Fields(lines[2])[:2])
+}
+
+func TestSimulate(t *testing.T) {
+	out, err := runCommand(t, "-nodes", "a,b,c", "-replicas", "100", "simulate", "-add", "d", "-keys", "10000")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "added d weight 1\n")
+	assert.Contains(t, out, "a -> d")
+	assert.NotContains(t, out, "a -> b")
+
+	var percent float64
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "moved ") {
+			_, err := fmt.Sscanf(line, "moved %f%%", &percent)
+			assert.NoError(t, err)
+		}
+	}
+	assert.InDelta(t, 25, percent, 5)
+
+	out, err = runCommand(t, "-nodes", "a,b,c", "simulate", "-remove", "c", "-add", "a=2")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "reweighted a weight 1 -> 2\n")
+	assert.Contains(t, out, "removed c\n")
+
+	_, err = runCommand(t, "-nodes", "a,b", "simulate", "-remove", "x")
+	assert.ErrorIs(t, err, hashring.ErrNodeNotFound)
+	_, err = runCommand(t, "-nodes", "a,b", "simulate")
+	assert.ErrorIs(t, err, errUsage)
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	oldConfig := filepath.Join(dir, "old.json")
+	newConfig := filepath.Join(dir, "new.txt")
+	assert.NoError(t, os.WriteFile(oldConfig, []byte(`{"a": 1, "b": 1}`), 0o644))
+	assert.NoError(t, os.WriteFile(newConfig, []byte("# new\na\nb\n"), 0o644))
+
+	out, err := runCommand(t, "-config", oldConfig, "diff", newConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, "moved 0.00% of 100000 keys\n", out)
+
+	_, err = runCommand(t, "-config", oldConfig, "-nodes", "a", "dist")
+	assert.ErrorContains(t, err, "mutually exclusive")
+	_, err = runCommand(t, "dist")
+	assert.ErrorContains(t, err, "no nodes")
+	_, err = runCommand(t, "-nodes", "a", "rebalance")
+	assert.ErrorIs(t, err, errUsage)
+}
@@ -0,0 +1,95 @@
+package hashring
+
+import "sort"
+
+// KeyRange is a range of the hash space which changed its owner between
+// two rings. It holds the keys k with Start <= k < End. A range with End
+// not greater than Start wraps around the end of the hash space.
+type KeyRange struct {
+	Start HashKey
+	End   HashKey
+	// From is the owner in the old ring and To the one in the new ring.
+	// An empty ring owns nothing, which is reported as "".
+	From string
+	To   string
+}
+
+// DiffRanges returns the ranges of the hash space whose owner differs
+// between oldRing and newRing, in clockwise order. Both rings must use
+// the same hash function. Adjacent ranges with the same owners are merged.
+// Overrides and hot keys are not taken into account.
+func DiffRanges(oldRing, newRing *HashRing) []KeyRange {
+	tokens := mergeTokens(oldRing.sortedKeys, newRing.sortedKeys)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	ranges := make([]KeyRange, 0)
+	// first is the index of the token ending the first range and last the
+	// index of the token ending the last one
+	first, last := -1, -1
+	for i, end := range tokens {
+		from, to := oldRing.ownerOf(end), newRing.ownerOf(end)
+		if from == to {
+			continue
+		}
+		if n := len(ranges); n > 0 && last == i-1 &&
+			ranges[n-1].From == from && ranges[n-1].To == to {
+			ranges[n-1].End = end
+		} else {
+			start := tokens[(i+len(tokens)-1)%len(tokens)]
+			ranges = append(ranges, KeyRange{Start: start, End: end, From: from, To: to})
+		}
+		if first < 0 {
+			first = i
+		}
+		last = i
+	}
+
+	// merge the range which wraps around with the first one
+	if n := len(ranges); n > 1 && first == 0 && last == len(tokens)-1 &&
+		ranges[n-1].From == ranges[0].From && ranges[n-1].To == ranges[0].To {
+		ranges[0].Start = ranges[n-1].Start
+		ranges = ranges[:n-1]
+	}
+	return ranges
+}
+
+// ownerOf returns the owner of the keys right below token, which is the
+// node of the first point not less than token.
+func (h *HashRing) ownerOf(token HashKey) string {
+	if len(h.sortedKeys) == 0 {
+		return ""
+	}
+	nodes := h.sortedKeys
+	pos := sort.Search(len(nodes), func(i int) bool { return !nodes[i].Less(token) })
+	if pos == len(nodes) {
+		pos = 0
+	}
+	return h.ring[nodes[pos]]
+}
+
+// mergeTokens merges two sorted token lists, dropping duplicates.
+func mergeTokens(a, b []HashKey) []HashKey {
+	merged := make([]HashKey, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		var next HashKey
+		switch {
+		case j == len(b) || (i < len(a) && a[i].Less(b[j])):
+			next = a[i]
+			i++
+		case i == len(a) || b[j].Less(a[i]):
+			next = b[j]
+			j++
+		default:
+			next = a[i]
+			i++
+			j++
+		}
+		if n := len(merged); n == 0 || merged[n-1].Less(next) {
+			merged = append(merged, next)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,68 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func inRange(key HashKey, r KeyRange) bool {
+	if r.Start.Less(r.End) {
+		return !key.Less(r.Start) && key.Less(r.End)
+	}
+	return !key.Less(r.Start) || key.Less(r.End)
+}
+
+func assertDiff(t *testing.T, oldRing, newRing *HashRing) {
+	ranges := DiffRanges(oldRing, newRing)
+	for i := 0; i < 2000; i++ {
+		stringKey := fmt.Sprintf("key%d", i)
+		key := oldRing.GenKey(stringKey)
+		from, _ := oldRing.GetNode(stringKey)
+		to, _ := newRing.GetNode(stringKey)
+
+		matched := make([]KeyRange, 0)
+		for _, r := range ranges {
+			if inRange(key, r) {
+				matched = append(matched, r)
+			}
+		}
+		if from == to {
+			assert.Empty(t, matched, stringKey)
+		} else if assert.Len(t, matched, 1, stringKey) {
+			assert.Equal(t, from, matched[0].From, stringKey)
+			assert.Equal(t, to, matched[0].To, stringKey)
+		}
+	}
+}
+
+func TestDiffRanges(t *testing.T) {
+	ring := New([]string{"a", "b", "c"}, WithReplicas(20))
+
+	assert.Empty(t, DiffRanges(ring, ring))
+	assertDiff(t, ring, ring.AddNode("d"))
+	assertDiff(t, ring, ring.RemoveNode("b"))
+	assertDiff(t, ring, ring.UpdateWeightedNode("c", 3))
+	assertDiff(t, ring, New([]string{"x", "y"}, WithReplicas(20)))
+
+	for _, r := range DiffRanges(ring, ring.AddNode("d")) {
+		assert.Equal(t, "d", r.To)
+	}
+	for _, r := range DiffRanges(ring, ring.RemoveNode("b")) {
+		assert.Equal(t, "b", r.From)
+	}
+}
+
+func TestDiffRangesEmpty(t *testing.T) {
+	empty := New(nil)
+	ring := New([]string{"a"})
+
+	assert.Nil(t, DiffRanges(empty, empty))
+	ranges := DiffRanges(empty, ring)
+	if assert.Len(t, ranges, 1) {
+		assert.Equal(t, "", ranges[0].From)
+		assert.Equal(t, "a", ranges[0].To)
+	}
+	assertDiff(t, ring, ring.AddNode("b"))
+}
@@ -0,0 +1,166 @@
+package hashring
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resolver looks up the DNS records used by DNSSource. It is implemented
+// by *net.Resolver.
+type Resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error)
+	LookupHost(ctx context.Context, host string) (addrs []string, err error)
+}
+
+// DNSSource keeps the membership of a ring in sync with DNS records. It
+// either resolves SRV records, or the A/AAAA records of a host name when
+// Port is set. Nodes are named "host:port".
+//
+// Only the SRV records with the lowest priority are used, the others are
+// backups. Node weights are the SRV weights scaled to a mean of 1, where a
+// weight of 0 counts as 1. Every address of a host gets weight 1. The ring
+// needs enough virtual points per node for the scaled weights to make a
+// difference, see WithReplicas: with the default of one point per unit of
+// weight, SRV weights of 60, 20 and 0 give the nodes 2, 1 and 1 points.
+type DNSSource struct {
+	// Service, Proto and Name are passed to LookupSRV.
+	Service string
+	Proto   string
+	Name    string
+	// Port, if set, makes DNSSource resolve Name with LookupHost.
+	Port int
+
+	Ring *AtomicRing
+	// Resolver defaults to net.DefaultResolver.
+	Resolver Resolver
+	// Interval is how often the records are resolved. Defaults to 30 seconds.
+	Interval time.Duration
+	// OnError, if set, is called with every error of Run.
+	OnError func(err error)
+}
+
+func NewSRVSource(service, proto, name string, ring *AtomicRing) *DNSSource {
+	return &DNSSource{
+		Service: service,
+		Proto:   proto,
+		Name:    name,
+		Ring:    ring,
+	}
+}
+
+func NewHostSource(host string, port int, ring *AtomicRing) *DNSSource {
+	return &DNSSource{
+		Name: host,
+		Port: port,
+		Ring: ring,
+	}
+}
+
+// Resolve looks up the records and returns the node weights they describe.
+func (s *DNSSource) Resolve(ctx context.Context) (map[string]float64, error) {
+	resolver := s.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	weights := make(map[string]float64)
+	if s.Port != 0 {
+		addrs, err := resolver.LookupHost(ctx, s.Name)
+		if err != nil {
+			return nil, err
+		}
+		port := strconv.Itoa(s.Port)
+		for _, addr := range addrs {
+			weights[net.JoinHostPort(addr, port)] = 1
+		}
+	} else {
+		_, records, err := resolver.LookupSRV(ctx, s.Service, s.Proto, s.Name)
+		if err != nil {
+			return nil, err
+		}
+		weights = srvWeights(records)
+	}
+
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("no records found for %s", s.Name)
+	}
+	return weights, nil
+}
+
+func srvWeights(records []*net.SRV) map[string]float64 {
+	weights := make(map[string]float64)
+	if len(records) == 0 {
+		return weights
+	}
+
+	priority := records[0].Priority
+	for _, record := range records[1:] {
+		priority = min(priority, record.Priority)
+	}
+
+	total := 0.0
+	for _, record := range records {
+		if record.Priority != priority {
+			continue
+		}
+		host := strings.TrimSuffix(record.Target, ".")
+		node := net.JoinHostPort(host, strconv.Itoa(int(record.Port)))
+		weight := float64(max(record.Weight, 1))
+		total += weight - weights[node]
+		weights[node] = weight
+	}
+
+	mean := total / float64(len(weights))
+	for node, weight := range weights {
+		weights[node] = weight / mean
+	}
+	return weights
+}
+
+// Refresh resolves the records and applies them to the ring with the
+// semantics of UpdateWithFloatWeights. The ring is left alone on errors.
+func (s *DNSSource) Refresh(ctx context.Context) error {
+	weights, err := s.Resolve(ctx)
+	if err != nil {
+		return err
+	}
+	s.Ring.UpdateWithFloatWeights(weights)
+	return nil
+}
+
+// Run refreshes the ring every Interval until ctx is done. It returns the
+// error of the initial refresh, the records are resolved again anyway.
+func (s *DNSSource) Run(ctx context.Context) error {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	err := s.Refresh(ctx)
+	if err != nil {
+		s.report(err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-ticker.C:
+			if refreshErr := s.Refresh(ctx); refreshErr != nil && ctx.Err() == nil {
+				s.report(refreshErr)
+			}
+		}
+	}
+}
+
+func (s *DNSSource) report(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+}
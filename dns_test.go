@@ -0,0 +1,109 @@
+package hashring
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubResolver struct {
+	mu    sync.Mutex
+	srv   []*net.SRV
+	hosts []string
+	err   error
+}
+
+func (r *stubResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return name, r.srv, r.err
+}
+
+func (r *stubResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.hosts, r.err
+}
+
+func (r *stubResolver) set(srv []*net.SRV, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.srv, r.err = srv, err
+}
+
+func TestDNSSourceSRV(t *testing.T) {
+	resolver := &stubResolver{srv: []*net.SRV{
+		{Target: "cache1.example.com.", Port: 11211, Priority: 10, Weight: 60},
+		{Target: "cache2.example.com.", Port: 11211, Priority: 10, Weight: 20},
+		{Target: "cache3.example.com.", Port: 11211, Priority: 10, Weight: 0},
+		{Target: "backup.example.com.", Port: 11211, Priority: 20, Weight: 100},
+	}}
+	ring := NewAtomicRing(New(nil))
+	source := NewSRVSource("memcache", "tcp", "example.com", ring)
+	source.Resolver = resolver
+
+	weights, err := source.Resolve(context.Background())
+	assert.NoError(t, err)
+	assert.InDeltaMapValues(t, map[string]float64{
+		"cache1.example.com:11211": 2.222,
+		"cache2.example.com:11211": 0.741,
+		"cache3.example.com:11211": 0.037,
+	}, weights, 0.001)
+
+	assert.NoError(t, source.Refresh(context.Background()))
+	assert.Equal(t, 3, ring.Load().Size())
+
+	resolver.set(nil, errors.New("timeout"))
+	assert.Error(t, source.Refresh(context.Background()))
+	assert.Equal(t, 3, ring.Load().Size())
+
+	resolver.set(nil, nil)
+	assert.Error(t, source.Refresh(context.Background()))
+	assert.Equal(t, 3, ring.Load().Size())
+}
+
+func TestDNSSourceHost(t *testing.T) {
+	resolver := &stubResolver{hosts: []string{"10.0.0.1", "10.0.0.2", "fd00::1"}}
+	ring := NewAtomicRing(New(nil))
+	source := NewHostSource("cache.example.com", 11211, ring)
+	source.Resolver = resolver
+
+	assert.NoError(t, source.Refresh(context.Background()))
+	nodes, ok := ring.GetNodes("key", 3)
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []string{"10.0.0.1:11211", "10.0.0.2:11211", "[fd00::1]:11211"}, nodes)
+}
+
+func TestDNSSourceRun(t *testing.T) {
+	resolver := &stubResolver{srv: []*net.SRV{
+		{Target: "cache1.", Port: 11211, Weight: 1},
+	}}
+	ring := NewAtomicRing(New(nil))
+	source := NewSRVSource("", "", "cache.example.com", ring)
+	source.Resolver = resolver
+	source.Interval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- source.Run(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		return ring.Load().Size() == 1
+	}, time.Second, time.Millisecond)
+
+	resolver.set([]*net.SRV{
+		{Target: "cache1.", Port: 11211, Weight: 1},
+		{Target: "cache2.", Port: 11211, Weight: 1},
+	}, nil)
+	assert.Eventually(t, func() bool {
+		return ring.Load().Size() == 2
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
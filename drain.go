@@ -0,0 +1,106 @@
+package hashring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNodeNotFound = errors.New("node is not in the ring")
+	ErrNodeExists   = errors.New("node is already in the ring")
+)
+
+// Ramp configures how Drain and RampUp change the weight of a node. The
+// weight changes by an equal share in each of Steps steps, Interval apart.
+// The ring needs enough virtual points per node for the intermediate
+// weights to make a difference, see WithReplicas.
+type Ramp struct {
+	Steps    int
+	Interval time.Duration
+	// OnStage, if set, is called after every step has been applied.
+	OnStage func(stage RampStage)
+}
+
+// RampStage describes a step applied by Drain or RampUp.
+type RampStage struct {
+	Node  string
+	Step  int
+	Steps int
+	// Weight is the weight of the node after the step, 0 if it was removed.
+	Weight float64
+	Ring   *HashRing
+}
+
+// Drain lowers the weight of node in steps until it is removed from the
+// ring, so its keys move to the other nodes gradually instead of all at
+// once. If ctx is done before, Drain returns ctx.Err() and leaves the node
+// at its current weight.
+func Drain(ctx context.Context, ring *AtomicRing, node string, ramp Ramp) error {
+	weight, ok := ring.Load().Weight(node)
+	if !ok {
+		return fmt.Errorf("can't drain %q: %w", node, ErrNodeNotFound)
+	}
+
+	steps := max(ramp.Steps, 1)
+	return ramp.run(ctx, func(step int) RampStage {
+		stage := RampStage{Node: node, Step: step, Steps: steps}
+		if step == steps {
+			_, stage.Ring = ring.Update(func(h *HashRing) *HashRing {
+				return h.RemoveNode(node)
+			})
+			return stage
+		}
+		stage.Weight = weight * float64(steps-step) / float64(steps)
+		_, stage.Ring = ring.Update(func(h *HashRing) *HashRing {
+			return h.UpdateFloatWeightedNode(node, stage.Weight)
+		})
+		return stage
+	})
+}
+
+// RampUp adds node to the ring with a fraction of weight and raises it in
+// steps until it reaches weight, which warms the node up the same way
+// Drain cools it down.
+func RampUp(ctx context.Context, ring *AtomicRing, node string, weight float64, ramp Ramp) error {
+	if _, ok := ring.Load().Weight(node); ok {
+		return fmt.Errorf("can't ramp up %q: %w", node, ErrNodeExists)
+	}
+
+	steps := max(ramp.Steps, 1)
+	return ramp.run(ctx, func(step int) RampStage {
+		stage := RampStage{Node: node, Step: step, Steps: steps}
+		stage.Weight = weight * float64(step) / float64(steps)
+		_, stage.Ring = ring.Update(func(h *HashRing) *HashRing {
+			if step == 1 {
+				return h.AddFloatWeightedNode(node, stage.Weight)
+			}
+			return h.UpdateFloatWeightedNode(node, stage.Weight)
+		})
+		return stage
+	})
+}
+
+func (ramp Ramp) run(ctx context.Context, apply func(step int) RampStage) error {
+	steps := max(ramp.Steps, 1)
+	for step := 1; step <= steps; step++ {
+		if step > 1 {
+			timer := time.NewTimer(ramp.Interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		stage := apply(step)
+		if ramp.OnStage != nil {
+			ramp.OnStage(stage)
+		}
+	}
+	return nil
+}
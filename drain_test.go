@@ -0,0 +1,72 @@
+package hashring
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrain(t *testing.T) {
+	ring := NewAtomicRing(NewWithWeights(map[string]int{"a": 2, "b": 2, "c": 2}, WithReplicas(10)))
+
+	stages := make([]RampStage, 0)
+	err := Drain(context.Background(), ring, "a", Ramp{
+		Steps:    4,
+		Interval: time.Millisecond,
+		OnStage:  func(stage RampStage) { stages = append(stages, stage) },
+	})
+	assert.NoError(t, err)
+
+	weights := make([]float64, 0)
+	for i, stage := range stages {
+		assert.Equal(t, i+1, stage.Step)
+		assert.Equal(t, 4, stage.Steps)
+		weights = append(weights, stage.Weight)
+	}
+	assert.Equal(t, []float64{1.5, 1, 0.5, 0}, weights)
+	assert.Len(t, stages[0].Ring.sortedKeys, 55)
+	assert.Same(t, ring.Load(), stages[3].Ring)
+	assert.Equal(t, 2, ring.Load().Size())
+
+	err = Drain(context.Background(), ring, "a", Ramp{Steps: 4})
+	assert.ErrorIs(t, err, ErrNodeNotFound)
+}
+
+func TestRampUp(t *testing.T) {
+	ring := NewAtomicRing(New([]string{"a", "b"}, WithReplicas(10)))
+
+	weights := make([]float64, 0)
+	err := RampUp(context.Background(), ring, "c", 2, Ramp{
+		Steps:    4,
+		Interval: time.Millisecond,
+		OnStage:  func(stage RampStage) { weights = append(weights, stage.Weight) },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.5, 1, 1.5, 2}, weights)
+
+	weight, ok := ring.Load().Weight("c")
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, weight)
+	assert.Len(t, ring.Load().sortedKeys, 40)
+
+	err = RampUp(context.Background(), ring, "c", 2, Ramp{Steps: 4})
+	assert.ErrorIs(t, err, ErrNodeExists)
+}
+
+func TestDrainCanceled(t *testing.T) {
+	ring := NewAtomicRing(NewWithWeights(map[string]int{"a": 4, "b": 4}, WithReplicas(10)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Drain(ctx, ring, "a", Ramp{
+		Steps:    4,
+		Interval: time.Hour,
+		OnStage:  func(RampStage) { cancel() },
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	weight, ok := ring.Load().Weight("a")
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, weight)
+}
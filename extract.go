@@ -0,0 +1,69 @@
+package hashring
+
+import (
+	"regexp"
+	"strings"
+)
+
+// KeyExtractor returns the part of a key which is hashed to find its node.
+// Keys with the same extracted part are stored on the same nodes.
+type KeyExtractor func(key string) string
+
+// HashTagExtractor hashes the hash tag of a key, the non-empty substring
+// between the first tag[0] and the first tag[1] after it, like Redis
+// Cluster with tag "{}" or twemproxy's hash_tag. Keys without a hash tag
+// are hashed as a whole.
+func HashTagExtractor(tag string) KeyExtractor {
+	if len(tag) != 2 {
+		panic("hashring: hash tag must consist of two characters")
+	}
+	open, close := tag[0], tag[1]
+	return func(key string) string {
+		start := strings.IndexByte(key, open)
+		if start < 0 {
+			return key
+		}
+		end := strings.IndexByte(key[start+1:], close)
+		if end <= 0 {
+			return key
+		}
+		return key[start+1 : start+1+end]
+	}
+}
+
+// PrefixExtractor hashes the prefix of a key up to the n-th occurrence of
+// delimiter, e.g. with ":" and 2 both "user:42:profile" and "user:42:feed"
+// are hashed as "user:42". Keys with fewer delimiters are hashed as a whole.
+// The delimiter must not be empty and n must be positive.
+func PrefixExtractor(delimiter string, n int) KeyExtractor {
+	if delimiter == "" {
+		panic("hashring: prefix delimiter must not be empty")
+	}
+	if n <= 0 {
+		panic("hashring: prefix must end at a positive number of delimiters")
+	}
+	return func(key string) string {
+		end := 0
+		for i := 0; i < n; i++ {
+			next := strings.Index(key[end:], delimiter)
+			if next < 0 {
+				return key
+			}
+			end += next + len(delimiter)
+		}
+		return key[:end-len(delimiter)]
+	}
+}
+
+// RegexpExtractor hashes the first capturing group of re, e.g.
+// `^user:(\d+):` hashes "user:42:profile" as "42". Keys which don't match
+// are hashed as a whole.
+func RegexpExtractor(re *regexp.Regexp) KeyExtractor {
+	return func(key string) string {
+		match := re.FindStringSubmatch(key)
+		if len(match) < 2 {
+			return key
+		}
+		return match[1]
+	}
+}
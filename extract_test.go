@@ -0,0 +1,64 @@
+package hashring
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractors(t *testing.T) {
+	tests := []struct {
+		extract  KeyExtractor
+		key      string
+		expected string
+	}{
+		{HashTagExtractor("{}"), "{user:42}:profile", "user:42"},
+		{HashTagExtractor("{}"), "user:{42}", "42"},
+		{HashTagExtractor("{}"), "{}user:42", "{}user:42"},
+		{HashTagExtractor("{}"), "user:42", "user:42"},
+		{HashTagExtractor("$$"), "user:$42$:feed", "42"},
+		{PrefixExtractor(":", 2), "user:42:profile", "user:42"},
+		{PrefixExtractor(":", 1), "user:42:profile", "user"},
+		{PrefixExtractor(":", 2), "user:42", "user:42"},
+		{PrefixExtractor("::", 1), "user::42", "user"},
+		{RegexpExtractor(regexp.MustCompile(`^user:(\d+):`)), "user:42:feed", "42"},
+		{RegexpExtractor(regexp.MustCompile(`^user:(\d+):`)), "order:42:feed", "order:42:feed"},
+		{RegexpExtractor(regexp.MustCompile(`^user:\d+:`)), "user:42:feed", "user:42:feed"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.extract(test.key), test.key)
+	}
+
+	assert.Panics(t, func() { HashTagExtractor("{") })
+	assert.Panics(t, func() { PrefixExtractor(":", 0) })
+	assert.Panics(t, func() { PrefixExtractor(":", -1) })
+	assert.Panics(t, func() { PrefixExtractor("", 1) })
+}
+
+func TestKeyExtractor(t *testing.T) {
+	ring := New([]string{"a", "b", "c"}, WithReplicas(40), WithKeyExtractor(PrefixExtractor(":", 2)))
+	plain := New([]string{"a", "b", "c"}, WithReplicas(40))
+
+	for i := 0; i < 50; i++ {
+		user := fmt.Sprintf("user:%d", i)
+		expected, _ := plain.GetNodes(user, 2)
+		for _, suffix := range []string{"profile", "feed"} {
+			nodes, _ := ring.GetNodes(user+":"+suffix, 2)
+			assert.Equal(t, expected, nodes, user)
+		}
+	}
+
+	// the extractor survives ring updates and pins match the whole key
+	overrides := NewOverrides()
+	overrides.Pin("user:1:feed", "d")
+	ring = New([]string{"a", "b", "c"}, WithKeyExtractor(PrefixExtractor(":", 2)), WithOverrides(overrides))
+	ring = ring.AddNode("d")
+	node, _ := ring.GetNode("user:1:feed")
+	assert.Equal(t, "d", node)
+	profile, _ := ring.GetNode("user:1:profile")
+	assert.Equal(t, ring.GenKey("user:1"), ring.GenKey("user:1:profile"))
+	expected, _ := ring.GetNode("user:1")
+	assert.Equal(t, expected, profile)
+}
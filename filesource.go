@@ -0,0 +1,208 @@
+package hashring
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseMembership parses a list of nodes and their weights. It accepts
+// a JSON object mapping nodes to weights, a JSON array of nodes with
+// weight 1, or text with one node per line optionally followed by its
+// weight:
+//
+//	# memcached tier
+//	192.168.0.246:11212
+//	192.168.0.247:11212 2
+//
+// The list must not be empty, nodes must not repeat and weights must be
+// positive.
+func ParseMembership(data []byte) (map[string]float64, error) {
+	trimmed := bytes.TrimSpace(data)
+	var weights map[string]float64
+	var err error
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		err = json.Unmarshal(trimmed, &weights)
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		var nodes []string
+		err = json.Unmarshal(trimmed, &nodes)
+		if err == nil {
+			weights, err = nodeWeights(nodes)
+		}
+	default:
+		weights, err = parseMembershipText(trimmed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid membership: %w", err)
+	}
+
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("invalid membership: no nodes")
+	}
+	for node, weight := range weights {
+		if node == "" {
+			return nil, fmt.Errorf("invalid membership: empty node name")
+		}
+		if !(weight > 0) || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("invalid membership: node %q has weight %v", node, weight)
+		}
+	}
+	return weights, nil
+}
+
+func nodeWeights(nodes []string) (map[string]float64, error) {
+	weights := make(map[string]float64, len(nodes))
+	for _, node := range nodes {
+		if _, ok := weights[node]; ok {
+			return nil, fmt.Errorf("duplicate node %q", node)
+		}
+		weights[node] = 1
+	}
+	return weights, nil
+}
+
+func parseMembershipText(data []byte) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: expected node and weight, got %q", line, text)
+		}
+
+		node, weight := fields[0], 1.0
+		if len(fields) == 2 {
+			var err error
+			weight, err = strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		if _, ok := weights[node]; ok {
+			return nil, fmt.Errorf("line %d: duplicate node %q", line, node)
+		}
+		weights[node] = weight
+	}
+	return weights, scanner.Err()
+}
+
+// FileSource keeps the membership of a ring in sync with a file listing
+// its nodes, in any of the formats accepted by ParseMembership. Changes
+// are applied with the semantics of UpdateWithFloatWeights: the ring is only
+// rebuilt if the weights changed and it keeps its options. If the file
+// can't be read or parsed, the last good ring stays in place.
+type FileSource struct {
+	Path string
+	Ring *AtomicRing
+	// Interval is how often the file is checked for changes. Defaults to
+	// one second.
+	Interval time.Duration
+	// Debounce is how long the file must stay unchanged before it is
+	// loaded, so a file which is being written is not picked up halfway.
+	// Defaults to Interval.
+	Debounce time.Duration
+	// OnError, if set, is called with every error of Run.
+	OnError func(err error)
+}
+
+func NewFileSource(path string, ring *AtomicRing) *FileSource {
+	return &FileSource{
+		Path: path,
+		Ring: ring,
+	}
+}
+
+// Load reads the file and applies it to the ring.
+func (s *FileSource) Load() error {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return err
+	}
+	weights, err := ParseMembership(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.Path, err)
+	}
+	s.Ring.UpdateWithFloatWeights(weights)
+	return nil
+}
+
+// Run loads the file and then watches it for changes until ctx is done.
+// It returns the error of the initial load, the file is watched anyway.
+func (s *FileSource) Run(ctx context.Context) error {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	debounce := s.Debounce
+	if debounce <= 0 {
+		debounce = interval
+	}
+
+	loaded, _ := s.stat()
+	err := s.Load()
+	if err != nil {
+		s.report(err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// seen is the last observed version of the file and seenAt the time it
+	// was first observed.
+	seen := loaded
+	var seenAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return err
+		case now := <-ticker.C:
+			version, statErr := s.stat()
+			if statErr != nil {
+				s.report(statErr)
+				continue
+			}
+			if version != seen {
+				seen, seenAt = version, now
+				continue
+			}
+			if version == loaded || now.Sub(seenAt) < debounce {
+				continue
+			}
+			loaded = version
+			if loadErr := s.Load(); loadErr != nil {
+				s.report(loadErr)
+			}
+		}
+	}
+}
+
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+func (s *FileSource) stat() (fileVersion, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return fileVersion{}, err
+	}
+	return fileVersion{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+func (s *FileSource) report(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+}
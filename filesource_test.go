@@ -0,0 +1,112 @@
+package hashring
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMembership(t *testing.T) {
+	for name, data := range map[string]string{
+		"object": `{"a": 1, "b": 2.5}`,
+		"text":   "# nodes\na\n\nb 2.5 # bigger\n",
+	} {
+		weights, err := ParseMembership([]byte(data))
+		assert.NoError(t, err, name)
+		assert.Equal(t, map[string]float64{"a": 1, "b": 2.5}, weights, name)
+	}
+
+	weights, err := ParseMembership([]byte(`["a", "b"]`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"a": 1, "b": 1}, weights)
+
+	for _, data := range []string{
+		``,
+		`{}`,
+		`{"a": 0}`,
+		`{"a": -1}`,
+		`{"": 1}`,
+		`["a", "a"]`,
+		`{"a": `,
+		"a\na",
+		"a x",
+		"a 1 2",
+	} {
+		_, err := ParseMembership([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func writeMembership(t *testing.T, path, data string, modTime time.Time) {
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestFileSourceLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nodes.txt")
+	ring := NewAtomicRing(New(nil, WithReplicas(10)))
+	source := NewFileSource(path, ring)
+
+	assert.Error(t, source.Load())
+
+	writeMembership(t, path, "a\nb 2\n", time.Now())
+	assert.NoError(t, source.Load())
+	loaded := ring.Load()
+	assert.Equal(t, 2, loaded.Size())
+	assert.Len(t, loaded.sortedKeys, 30)
+
+	// unchanged weights don't rebuild the ring
+	writeMembership(t, path, `{"a": 1, "b": 2}`, time.Now())
+	assert.NoError(t, source.Load())
+	assert.Same(t, loaded, ring.Load())
+
+	// the last good ring is kept
+	writeMembership(t, path, `{"a": 1, "b": `, time.Now())
+	assert.Error(t, source.Load())
+	assert.Same(t, loaded, ring.Load())
+}
+
+func TestFileSourceRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nodes.txt")
+	start := time.Now().Add(-time.Hour)
+	writeMembership(t, path, "a\nb\n", start)
+
+	ring := NewAtomicRing(New(nil))
+	errs := make(chan error, 10)
+	source := &FileSource{
+		Path:     path,
+		Ring:     ring,
+		Interval: 5 * time.Millisecond,
+		Debounce: 20 * time.Millisecond,
+		OnError:  func(err error) { errs <- err },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- source.Run(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		return ring.Load().Size() == 2
+	}, time.Second, time.Millisecond)
+
+	writeMembership(t, path, "a\nb\nc\n", start.Add(time.Second))
+	assert.Eventually(t, func() bool {
+		return ring.Load().Size() == 3
+	}, time.Second, time.Millisecond)
+
+	writeMembership(t, path, "a\nb 0\n", start.Add(2*time.Second))
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, `node "b" has weight 0`)
+	case <-time.After(time.Second):
+		t.Error("invalid membership was not reported")
+	}
+	assert.Equal(t, 3, ring.Load().Size())
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,109 @@
+// Package grpcbalancer provides a gRPC load balancing policy which sends
+// every call to the backend owning the call's key on a consistent hashing
+// ring. The key is taken from the outgoing metadata of the call:
+//
+//	grpcbalancer.Register("x-shard-key", hashring.WithReplicas(160))
+//	conn, err := grpc.NewClient(target,
+//		grpc.WithDefaultServiceConfig(`{"loadBalancingConfig": [{"hashring": {}}]}`),
+//		...
+//	)
+//	ctx = metadata.AppendToOutgoingContext(ctx, "x-shard-key", userID)
+//
+// The ring is made of the backends whose connections are ready and is
+// rebuilt whenever a connection changes its state. Calls without a key
+// are spread randomly.
+package grpcbalancer
+
+import (
+	"math/rand/v2"
+
+	"github.com/serialx/hashring"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+)
+
+// Name is the name the balancer is registered with by Register.
+const Name = "hashring"
+
+// Register registers the balancer under Name.
+func Register(metadataKey string, opts ...hashring.Option) {
+	balancer.Register(NewBuilder(Name, metadataKey, opts...))
+}
+
+// NewBuilder creates a balancer builder which can be registered with
+// balancer.Register. The key of a call is the first value of metadataKey
+// in its outgoing metadata. opts configure the ring.
+func NewBuilder(name, metadataKey string, opts ...hashring.Option) balancer.Builder {
+	return base.NewBalancerBuilder(name, &pickerBuilder{
+		metadataKey: metadataKey,
+		opts:        opts,
+	}, base.Config{HealthCheck: true})
+}
+
+type weightKey struct{}
+
+// WithWeight returns addr with the weight of the backend in the ring.
+// Addresses without a weight have weight 1. The weight is part of the
+// address, so changing it replaces the connection to the backend.
+func WithWeight(addr resolver.Address, weight float64) resolver.Address {
+	addr.Attributes = addr.Attributes.WithValue(weightKey{}, weight)
+	return addr
+}
+
+// Weight returns the weight set by WithWeight.
+func Weight(addr resolver.Address) float64 {
+	if weight, ok := addr.Attributes.Value(weightKey{}).(float64); ok {
+		return weight
+	}
+	return 1
+}
+
+type pickerBuilder struct {
+	metadataKey string
+	opts        []hashring.Option
+}
+
+func (b *pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	weights := make(map[string]float64, len(info.ReadySCs))
+	subConns := make(map[string]balancer.SubConn, len(info.ReadySCs))
+	all := make([]balancer.SubConn, 0, len(info.ReadySCs))
+	for subConn, subConnInfo := range info.ReadySCs {
+		weights[subConnInfo.Address.Addr] = Weight(subConnInfo.Address)
+		subConns[subConnInfo.Address.Addr] = subConn
+		all = append(all, subConn)
+	}
+
+	return &picker{
+		metadataKey: b.metadataKey,
+		ring:        hashring.NewWithFloatWeights(weights, b.opts...),
+		subConns:    subConns,
+		all:         all,
+	}
+}
+
+type picker struct {
+	metadataKey string
+	ring        *hashring.HashRing
+	subConns    map[string]balancer.SubConn
+	all         []balancer.SubConn
+}
+
+func (p *picker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	md, _ := metadata.FromOutgoingContext(info.Ctx)
+	values := md.Get(p.metadataKey)
+	if len(values) == 0 {
+		return balancer.PickResult{SubConn: p.all[rand.IntN(len(p.all))]}, nil
+	}
+
+	node, ok := p.ring.GetNode(values[0])
+	if !ok {
+		return balancer.PickResult{}, balancer.ErrNoSubConnAvailable
+	}
+	return balancer.PickResult{SubConn: p.subConns[node]}, nil
+}
@@ -0,0 +1,108 @@
+package grpcbalancer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/serialx/hashring"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const testBalancer = "hashring_test"
+
+func init() {
+	balancer.Register(NewBuilder(testBalancer, "x-key", hashring.WithReplicas(40)))
+}
+
+// startServers starts in-process servers which report their address in
+// the "server" response header of every call.
+func startServers(t *testing.T, addrs ...string) map[string]*bufconn.Listener {
+	listeners := make(map[string]*bufconn.Listener)
+	for _, addr := range addrs {
+		listener := bufconn.Listen(1 << 16)
+		server := grpc.NewServer(grpc.UnknownServiceHandler(func(srv any, stream grpc.ServerStream) error {
+			if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+				return err
+			}
+			if err := stream.SetHeader(metadata.Pairs("server", addr)); err != nil {
+				return err
+			}
+			return stream.SendMsg(&emptypb.Empty{})
+		}))
+		go server.Serve(listener)
+		t.Cleanup(server.Stop)
+		listeners[addr] = listener
+	}
+	return listeners
+}
+
+func call(ctx context.Context, conn *grpc.ClientConn, key string) (string, error) {
+	if key != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-key", key)
+	}
+	var header metadata.MD
+	err := conn.Invoke(ctx, "/test.Echo/Call", &emptypb.Empty{}, &emptypb.Empty{}, grpc.Header(&header))
+	if err != nil {
+		return "", err
+	}
+	return header.Get("server")[0], nil
+}
+
+func TestBalancer(t *testing.T) {
+	listeners := startServers(t, "backend-a", "backend-b", "backend-c")
+	manager := hashring.NewRingManager(hashring.NewAtomicRing(hashring.New(nil)))
+	for addr := range listeners {
+		manager.Apply(hashring.Event{Type: hashring.NodeAdded, Node: addr, Weight: 1})
+	}
+
+	conn, err := grpc.NewClient("ringtest:///echo",
+		grpc.WithResolvers(NewResolverBuilder("ringtest", manager)),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return listeners[addr].DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{%q: {}}]}`, testBalancer)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	expected := hashring.New([]string{"backend-a", "backend-b", "backend-c"}, hashring.WithReplicas(40))
+	routedByRing := func(ring *hashring.HashRing) func() bool {
+		return func() bool {
+			for i := 0; i < 30; i++ {
+				key := fmt.Sprintf("user%d", i)
+				node, _ := ring.GetNode(key)
+				server, err := call(ctx, conn, key)
+				if err != nil || server != node {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	assert.Eventually(t, routedByRing(expected), 5*time.Second, 10*time.Millisecond)
+
+	_, err = call(ctx, conn, "")
+	assert.NoError(t, err)
+
+	manager.Apply(hashring.Event{Type: hashring.NodeRemoved, Node: "backend-b"})
+	assert.Eventually(t, routedByRing(expected.RemoveNode("backend-b")), 5*time.Second, 10*time.Millisecond)
+
+	manager.Apply(hashring.Event{Type: hashring.NodeReweighted, Node: "backend-c", Weight: 3})
+	assert.Eventually(t, routedByRing(expected.RemoveNode("backend-b").UpdateWeightedNode("backend-c", 3)),
+		5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,62 @@
+package grpcbalancer
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/serialx/hashring"
+	"google.golang.org/grpc/resolver"
+)
+
+// NewResolverBuilder creates a resolver builder for scheme which resolves
+// every target to the nodes of the ring of manager, with their weights,
+// and pushes the membership changes applied by manager to gRPC.
+func NewResolverBuilder(scheme string, manager *hashring.RingManager) resolver.Builder {
+	return &resolverBuilder{scheme: scheme, manager: manager}
+}
+
+type resolverBuilder struct {
+	scheme  string
+	manager *hashring.RingManager
+}
+
+func (b *resolverBuilder) Scheme() string {
+	return b.scheme
+}
+
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	r := &ringResolver{cc: cc, ring: b.manager.Ring()}
+	r.cancel = b.manager.Subscribe(func(hashring.RingChange) {
+		r.update()
+	})
+	r.update()
+	return r, nil
+}
+
+type ringResolver struct {
+	mu     sync.Mutex
+	cc     resolver.ClientConn
+	ring   *hashring.AtomicRing
+	cancel func()
+}
+
+// update pushes the current ring to gRPC. It loads the ring under the lock,
+// so concurrent updates can't overwrite a newer ring with an older one.
+func (r *ringResolver) update() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	weights := r.ring.Load().Weights()
+	addrs := make([]resolver.Address, 0, len(weights))
+	for node, weight := range weights {
+		addrs = append(addrs, WithWeight(resolver.Address{Addr: node}, weight))
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Addr < addrs[j].Addr })
+	r.cc.UpdateState(resolver.State{Addresses: addrs})
+}
+
+func (r *ringResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *ringResolver) Close() {
+	r.cancel()
+}
@@ -3,6 +3,8 @@ package hashring
 import (
 	"crypto/md5"
 	"fmt"
+	"maps"
+	"math"
 	"sort"
 	"strconv"
 )
@@ -28,12 +30,33 @@ func (h HashKeyOrder) Less(i, j int) bool {
 
 type HashFunc func([]byte) HashKey
 
+// VNodeKeyFunc returns the key which is hashed to place the replica-th
+// virtual point of node on the ring.
+type VNodeKeyFunc func(node string, replica int) string
+
+// DefaultVNodeKey names virtual points as "node-replica". It only matches
+// the naming of the Python hash_ring library, which places several points
+// per hashed name, so the points themselves differ.
+func DefaultVNodeKey(node string, replica int) string {
+	return node + "-" + strconv.FormatInt(int64(replica), 10)
+}
+
 type HashRing struct {
 	ring       map[HashKey]string
 	sortedKeys []HashKey
+	// sortKeys holds sortedKeys as sort keys for GetNodesBatch, or is nil
+	// if they are not built-in hash keys
+	sortKeys   []sortKey
 	nodes      []string
-	weights    map[string]int
+	weights    map[string]float64
 	hashFunc   HashFunc
+	vnodeKey   VNodeKeyFunc
+	replicas   int
+	overrides  *Overrides
+	hotKeys    *hotKeys
+	labels     map[string]Labels
+	extractKey KeyExtractor
+	observer   Observer
 }
 
 type Uint32HashKey uint32
@@ -42,43 +65,100 @@ func (k Uint32HashKey) Less(other HashKey) bool {
 	return k < other.(Uint32HashKey)
 }
 
-func New(nodes []string) *HashRing {
-	return NewWithHash(nodes, defaultHashFunc)
+func New(nodes []string, opts ...Option) *HashRing {
+	return NewWithHash(nodes, defaultHashFunc, opts...)
 }
 
 func NewWithHash(
 	nodes []string,
 	hashKey HashFunc,
+	opts ...Option,
 ) *HashRing {
-	hashRing := &HashRing{
-		ring:       make(map[HashKey]string),
-		sortedKeys: make([]HashKey, 0),
-		nodes:      nodes,
-		weights:    make(map[string]int),
-		hashFunc:   hashKey,
-	}
+	hashRing := newHashRing(nodes, make(map[string]float64), hashKey, opts)
 	hashRing.generateCircle()
 	return hashRing
 }
 
-func NewWithWeights(weights map[string]int) *HashRing {
-	return NewWithHashAndWeights(weights, defaultHashFunc)
+func NewWithWeights(weights map[string]int, opts ...Option) *HashRing {
+	return NewWithHashAndWeights(weights, defaultHashFunc, opts...)
 }
 
 func NewWithHashAndWeights(
 	weights map[string]int,
 	hashFunc HashFunc,
+	opts ...Option,
+) *HashRing {
+	return NewWithHashAndFloatWeights(floatWeights(weights), hashFunc, opts...)
+}
+
+// NewWithFloatWeights creates a ring with fractional weights. Use it
+// together with WithReplicas, e.g. a weight of 1.5 with 100 replicas
+// places 150 virtual points of the node on the ring.
+func NewWithFloatWeights(weights map[string]float64, opts ...Option) *HashRing {
+	return NewWithHashAndFloatWeights(weights, defaultHashFunc, opts...)
+}
+
+func NewWithHashAndFloatWeights(
+	weights map[string]float64,
+	hashFunc HashFunc,
+	opts ...Option,
 ) *HashRing {
 	nodes := make([]string, 0, len(weights))
 	for node := range weights {
 		nodes = append(nodes, node)
 	}
+	hashRing := newHashRing(nodes, weights, hashFunc, opts)
+	hashRing.generateCircle()
+	return hashRing
+}
+
+func newHashRing(
+	nodes []string,
+	weights map[string]float64,
+	hashFunc HashFunc,
+	opts []Option,
+) *HashRing {
 	hashRing := &HashRing{
 		ring:       make(map[HashKey]string),
 		sortedKeys: make([]HashKey, 0),
 		nodes:      nodes,
 		weights:    weights,
 		hashFunc:   hashFunc,
+		vnodeKey:   DefaultVNodeKey,
+		replicas:   1,
+		labels:     make(map[string]Labels),
+	}
+	for _, opt := range opts {
+		opt(hashRing)
+	}
+	return hashRing
+}
+
+// derive creates a new ring with the given nodes and weights which shares
+// the configuration (hash function, options) of h.
+func (h *HashRing) derive(nodes []string, weights map[string]float64) *HashRing {
+	hashRing := h.rebuild(nodes, weights)
+	if h.observer != nil {
+		h.observer.OnRebuild(h, hashRing)
+	}
+	return hashRing
+}
+
+// rebuild is derive without notifying the observer.
+func (h *HashRing) rebuild(nodes []string, weights map[string]float64) *HashRing {
+	hashRing := &HashRing{
+		ring:       make(map[HashKey]string),
+		sortedKeys: make([]HashKey, 0),
+		nodes:      nodes,
+		weights:    weights,
+		hashFunc:   h.hashFunc,
+		vnodeKey:   h.vnodeKey,
+		replicas:   h.replicas,
+		overrides:  h.overrides,
+		hotKeys:    h.hotKeys,
+		labels:     h.labels,
+		extractKey: h.extractKey,
+		observer:   h.observer,
 	}
 	hashRing.generateCircle()
 	return hashRing
@@ -88,7 +168,39 @@ func (h *HashRing) Size() int {
 	return len(h.nodes)
 }
 
+// Weight returns the weight of node.
+func (h *HashRing) Weight(node string) (weight float64, ok bool) {
+	weight, ok = h.weights[node]
+	return weight, ok
+}
+
+// Weights returns a copy of the weights of all nodes.
+func (h *HashRing) Weights() map[string]float64 {
+	return maps.Clone(h.weights)
+}
+
 func (h *HashRing) UpdateWithWeights(weights map[string]int) {
+	h.UpdateWithFloatWeights(floatWeights(weights))
+}
+
+func (h *HashRing) UpdateWithFloatWeights(weights map[string]float64) {
+	// derive from a copy, so observers get a snapshot of the old ring
+	// rather than h, which is updated in place
+	oldhring := *h
+	newhring := oldhring.withWeights(weights)
+	if newhring != &oldhring {
+		h.weights = newhring.weights
+		h.nodes = newhring.nodes
+		h.ring = newhring.ring
+		h.sortedKeys = newhring.sortedKeys
+		h.sortKeys = newhring.sortKeys
+		h.labels = newhring.labels
+	}
+}
+
+// withWeights returns a ring with the given weights and the configuration
+// of h. It returns h itself if the weights didn't change.
+func (h *HashRing) withWeights(weights map[string]float64) *HashRing {
 	nodesChgFlg := false
 	if len(weights) != len(h.weights) {
 		nodesChgFlg = true
@@ -102,31 +214,30 @@ func (h *HashRing) UpdateWithWeights(weights map[string]int) {
 		}
 	}
 
-	if nodesChgFlg {
-		newhring := NewWithHashAndWeights(weights, h.hashFunc)
-		h.weights = newhring.weights
-		h.nodes = newhring.nodes
-		h.ring = newhring.ring
-		h.sortedKeys = newhring.sortedKeys
+	if !nodesChgFlg {
+		return h
+	}
+
+	nodes := make([]string, 0, len(weights))
+	for node := range weights {
+		nodes = append(nodes, node)
 	}
+	return h.derive(nodes, weights)
 }
 
 func (h *HashRing) generateCircle() {
-	totalWeight := 0
 	for _, node := range h.nodes {
-		if weight, ok := h.weights[node]; ok {
-			totalWeight += weight
-		} else {
-			totalWeight += 1
+		if _, ok := h.weights[node]; !ok {
 			h.weights[node] = 1
 		}
 	}
+	h.labels = memberLabels(h.labels, h.weights)
 
 	for _, node := range h.nodes {
-		weight := h.weights[node]
+		points := h.points(h.weights[node])
 
-		for j := 0; j < weight; j++ {
-			nodeKey := node + "-" + strconv.FormatInt(int64(j), 10)
+		for j := 0; j < points; j++ {
+			nodeKey := h.vnodeKey(node, j)
 			key := h.hashFunc([]byte(nodeKey))
 			h.ring[key] = node
 			h.sortedKeys = append(h.sortedKeys, key)
@@ -134,14 +245,62 @@ func (h *HashRing) generateCircle() {
 	}
 
 	sort.Sort(HashKeyOrder(h.sortedKeys))
+	h.sortKeys = sortKeysOf(h.sortedKeys)
 }
 
-func (h *HashRing) GetNode(stringKey string) (node string, ok bool) {
-	pos, ok := h.GetNodePos(stringKey)
+// points returns the number of virtual points of a node with the given
+// weight. Every node gets at least one point.
+func (h *HashRing) points(weight float64) int {
+	points := int(math.Round(weight * float64(h.replicas)))
+	if points < 1 {
+		return 1
+	}
+	return points
+}
+
+// Overrides returns the key pins consulted by GetNode and GetNodes, or nil
+// if the ring was created without WithOverrides.
+func (h *HashRing) Overrides() *Overrides {
+	return h.overrides
+}
+
+// pinned returns the node stringKey is pinned to if that node is a member
+// of the ring.
+func (h *HashRing) pinned(stringKey string) (node string, ok bool) {
+	if h.overrides == nil {
+		return "", false
+	}
+	node, ok = h.overrides.Lookup(stringKey)
 	if !ok {
 		return "", false
 	}
-	return h.ring[h.sortedKeys[pos]], true
+	_, ok = h.weights[node]
+	return node, ok
+}
+
+func (h *HashRing) GetNode(stringKey string) (node string, ok bool) {
+	node, pos, ok := h.getNode(stringKey)
+	if ok && h.observer != nil {
+		h.observer.OnLookup(stringKey, node, pos)
+	}
+	return node, ok
+}
+
+// getNode returns the node of stringKey and the position of the point it
+// was found at, or -1 if the key was routed by overrides or hot keys.
+func (h *HashRing) getNode(stringKey string) (node string, pos int, ok bool) {
+	if node, ok := h.pinned(stringKey); ok {
+		return node, -1, true
+	}
+	if node, ok := h.hotNode(stringKey); ok {
+		return node, -1, true
+	}
+
+	pos, ok = h.GetNodePos(stringKey)
+	if !ok {
+		return "", 0, false
+	}
+	return h.ring[h.sortedKeys[pos]], pos, true
 }
 
 func (h *HashRing) GetNodePos(stringKey string) (pos int, ok bool) {
@@ -149,53 +308,125 @@ func (h *HashRing) GetNodePos(stringKey string) (pos int, ok bool) {
 		return 0, false
 	}
 
-	key := h.GenKey(stringKey)
+	return h.search(h.GenKey(stringKey)), true
+}
 
+// search returns the position of the first point after key, wrapping
+// around to 0 past the last one.
+func (h *HashRing) search(key HashKey) int {
 	nodes := h.sortedKeys
-	pos = sort.Search(len(nodes), func(i int) bool { return key.Less(nodes[i]) })
+	pos := sort.Search(len(nodes), func(i int) bool { return key.Less(nodes[i]) })
 
 	if pos == len(nodes) {
 		// Wrap the search, should return First node
-		return 0, true
-	} else {
-		return pos, true
+		return 0
 	}
+	return pos
 }
 
+// Owner returns the node owning stringKey: the node it is pinned to by
+// overrides, or the node of its position on the ring. Unlike GetNode it
+// doesn't spread hot keys over their replicas and doesn't notify the
+// observer, so it suits analyses which must not affect live traffic.
+func (h *HashRing) Owner(stringKey string) (node string, ok bool) {
+	if node, ok := h.pinned(stringKey); ok {
+		return node, true
+	}
+	pos, ok := h.GetNodePos(stringKey)
+	if !ok {
+		return "", false
+	}
+	return h.ring[h.sortedKeys[pos]], true
+}
+
+// GenKey returns the position of key on the ring. Only the part of the key
+// returned by the KeyExtractor of the ring is hashed, if one is set.
 func (h *HashRing) GenKey(key string) HashKey {
+	if h.extractKey != nil {
+		key = h.extractKey(key)
+	}
 	return h.hashFunc([]byte(key))
 }
 
 // GetNodes iterates over the hash ring and returns the nodes in the order
-// which is determined by the key. GetNodes is thread safe if the hash
+// which is determined by the key. A node the key is pinned to by
+// overrides comes first. GetNodes is thread safe if the hash
 // which was used to configure the hash ring is thread safe.
 func (h *HashRing) GetNodes(stringKey string, size int) (nodes []string, ok bool) {
-	pos, ok := h.GetNodePos(stringKey)
-	if !ok {
+	if size > len(h.nodes) {
 		return nil, false
 	}
 
-	if size > len(h.nodes) {
-		return nil, false
+	return h.GetNodesFunc(stringKey, size, nil)
+}
+
+// GetNodesFunc works like GetNodes but only returns nodes for which accept
+// returns true, e.g. to skip nodes which are draining or overloaded. It
+// walks the ring clockwise from the position of the key and returns the
+// first size distinct accepted nodes. If fewer nodes are accepted, it
+// returns those with ok set to false. A nil accept accepts every node.
+func (h *HashRing) GetNodesFunc(
+	stringKey string,
+	size int,
+	accept func(node string) bool,
+) (nodes []string, ok bool) {
+	nodes, pos, ok := h.walk(stringKey, size, accept)
+	if len(nodes) > 0 && h.observer != nil {
+		h.observer.OnLookup(stringKey, nodes[0], pos)
+	}
+	return nodes, ok
+}
+
+// walk implements GetNodesFunc. It also returns the position of the point
+// the first node was found at, or -1 if it was pinned by overrides.
+func (h *HashRing) walk(
+	stringKey string,
+	size int,
+	accept func(node string) bool,
+) (nodes []string, firstPos int, ok bool) {
+	pos, ok := h.GetNodePos(stringKey)
+	if !ok {
+		return nil, 0, false
 	}
 
-	returnedValues := make(map[string]bool, size)
-	//mergedSortedKeys := append(h.sortedKeys[pos:], h.sortedKeys[:pos]...)
-	resultSlice := make([]string, 0, size)
+	returnedValues := make(map[string]bool, min(size, len(h.nodes)))
+	resultSlice := make([]string, 0, min(size, len(h.nodes)))
 
-	for i := pos; i < pos+len(h.sortedKeys); i++ {
+	firstPos = -1
+	if node, ok := h.pinned(stringKey); ok && size > 0 {
+		returnedValues[node] = true
+		if h.accepts(stringKey, node, accept) {
+			resultSlice = append(resultSlice, node)
+		}
+	}
+
+	for i := pos; i < pos+len(h.sortedKeys) && len(resultSlice) < size; i++ {
 		key := h.sortedKeys[i%len(h.sortedKeys)]
 		val := h.ring[key]
 		if !returnedValues[val] {
 			returnedValues[val] = true
-			resultSlice = append(resultSlice, val)
-		}
-		if len(returnedValues) == size {
-			break
+			if h.accepts(stringKey, val, accept) {
+				if len(resultSlice) == 0 {
+					firstPos = i % len(h.sortedKeys)
+				}
+				resultSlice = append(resultSlice, val)
+			}
 		}
 	}
 
-	return resultSlice, len(resultSlice) == size
+	return resultSlice, firstPos, len(resultSlice) == size
+}
+
+// accepts reports whether accept accepts node, notifying the observer of
+// rejected nodes. A nil accept accepts every node.
+func (h *HashRing) accepts(stringKey, node string, accept func(node string) bool) bool {
+	if accept == nil || accept(node) {
+		return true
+	}
+	if h.observer != nil {
+		h.observer.OnNodeSkipped(stringKey, node)
+	}
+	return false
 }
 
 func (h *HashRing) AddNode(node string) *HashRing {
@@ -203,6 +434,10 @@ func (h *HashRing) AddNode(node string) *HashRing {
 }
 
 func (h *HashRing) AddWeightedNode(node string, weight int) *HashRing {
+	return h.AddFloatWeightedNode(node, float64(weight))
+}
+
+func (h *HashRing) AddFloatWeightedNode(node string, weight float64) *HashRing {
 	if weight <= 0 {
 		return h
 	}
@@ -215,24 +450,20 @@ func (h *HashRing) AddWeightedNode(node string, weight int) *HashRing {
 	copy(nodes, h.nodes)
 	nodes = append(nodes, node)
 
-	weights := make(map[string]int)
+	weights := make(map[string]float64)
 	for eNode, eWeight := range h.weights {
 		weights[eNode] = eWeight
 	}
 	weights[node] = weight
 
-	hashRing := &HashRing{
-		ring:       make(map[HashKey]string),
-		sortedKeys: make([]HashKey, 0),
-		nodes:      nodes,
-		weights:    weights,
-		hashFunc:   h.hashFunc,
-	}
-	hashRing.generateCircle()
-	return hashRing
+	return h.derive(nodes, weights)
 }
 
 func (h *HashRing) UpdateWeightedNode(node string, weight int) *HashRing {
+	return h.UpdateFloatWeightedNode(node, float64(weight))
+}
+
+func (h *HashRing) UpdateFloatWeightedNode(node string, weight float64) *HashRing {
 	if weight <= 0 {
 		return h
 	}
@@ -245,22 +476,15 @@ func (h *HashRing) UpdateWeightedNode(node string, weight int) *HashRing {
 	nodes := make([]string, len(h.nodes))
 	copy(nodes, h.nodes)
 
-	weights := make(map[string]int)
+	weights := make(map[string]float64)
 	for eNode, eWeight := range h.weights {
 		weights[eNode] = eWeight
 	}
 	weights[node] = weight
 
-	hashRing := &HashRing{
-		ring:       make(map[HashKey]string),
-		sortedKeys: make([]HashKey, 0),
-		nodes:      nodes,
-		weights:    weights,
-		hashFunc:   h.hashFunc,
-	}
-	hashRing.generateCircle()
-	return hashRing
+	return h.derive(nodes, weights)
 }
+
 func (h *HashRing) RemoveNode(node string) *HashRing {
 	/* if node isn't exist in hashring, don't refresh hashring */
 	if _, ok := h.weights[node]; !ok {
@@ -274,20 +498,20 @@ func (h *HashRing) RemoveNode(node string) *HashRing {
 		}
 	}
 
-	weights := make(map[string]int)
+	weights := make(map[string]float64)
 	for eNode, eWeight := range h.weights {
 		if eNode != node {
 			weights[eNode] = eWeight
 		}
 	}
 
-	hashRing := &HashRing{
-		ring:       make(map[HashKey]string),
-		sortedKeys: make([]HashKey, 0),
-		nodes:      nodes,
-		weights:    weights,
-		hashFunc:   h.hashFunc,
+	return h.derive(nodes, weights)
+}
+
+func floatWeights(weights map[string]int) map[string]float64 {
+	result := make(map[string]float64, len(weights))
+	for node, weight := range weights {
+		result[node] = float64(weight)
 	}
-	hashRing.generateCircle()
-	return hashRing
+	return result
 }
@@ -9,7 +9,7 @@ import (
 )
 
 func expectWeights(t *testing.T, ring *HashRing, expectedWeights map[string]int) {
-	weightsEquality := reflect.DeepEqual(ring.weights, expectedWeights)
+	weightsEquality := reflect.DeepEqual(ring.weights, floatWeights(expectedWeights))
 	if !weightsEquality {
 		t.Error("Weights expected", expectedWeights, "but got", ring.weights)
 	}
@@ -561,3 +561,34 @@ func TestAddRemoveNode(t *testing.T) {
 	expectNodesABC(t, "TestAddRemoveNode_6_", ring)
 	expectNodeRangesABC(t, "", ring)
 }
+
+func TestGetNodesFunc(t *testing.T) {
+	nodes := []string{"a", "b", "c"}
+	ring := New(nodes)
+
+	draining := map[string]bool{"a": true}
+	accept := func(node string) bool { return !draining[node] }
+
+	// "test" is stored on [a c b]
+	result, ok := ring.GetNodesFunc("test", 2, accept)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"c", "b"}, result)
+
+	result, ok = ring.GetNodesFunc("test", 3, accept)
+	assert.False(t, ok)
+	assert.Equal(t, []string{"c", "b"}, result)
+
+	result, ok = ring.GetNodesFunc("test", 2, nil)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", "c"}, result)
+
+	overrides := NewOverrides()
+	overrides.Pin("test", "b")
+	ring = New(nodes, WithOverrides(overrides))
+	result, _ = ring.GetNodesFunc("test", 2, accept)
+	assert.Equal(t, []string{"b", "c"}, result)
+
+	draining["b"] = true
+	result, _ = ring.GetNodesFunc("test", 1, accept)
+	assert.Equal(t, []string{"c"}, result)
+}
@@ -0,0 +1,164 @@
+package hashring
+
+import (
+	"maps"
+	"strconv"
+)
+
+// Locator maps keys to nodes. It is implemented by *HashRing and
+// *HierarchicalRing, so hierarchies can be nested, e.g. region -> cluster
+// -> node.
+type Locator interface {
+	GetNode(stringKey string) (node string, ok bool)
+	GetNodes(stringKey string, size int) (nodes []string, ok bool)
+}
+
+// groupHashSalt returns the salt prepended to everything hashed by the
+// group ring of a level of a hierarchy. It decorrelates the lookups of the
+// levels which would otherwise hash to the same position, leaving the
+// nodes of a group whose points fall outside the group's arcs without any
+// keys. Every level has its own salt, as equally salted levels correlate
+// just the same. Salting the hash rather than the key keeps key
+// extractors and overrides of the group ring working on the original
+// keys.
+func groupHashSalt(level int) string {
+	return "group" + strconv.Itoa(level) + ":"
+}
+
+// HierarchicalRing hashes a key to a group (a region or a cluster) with
+// one ring and then to a node with the group's own Locator. Adding or
+// removing a group only moves the keys of that group, and changing the
+// members of a group never affects the placement in other groups.
+//
+// Like HashRing, a HierarchicalRing is never modified in place: AddGroup,
+// RemoveGroup and UpdateGroup return new rings.
+type HierarchicalRing struct {
+	groups  *HashRing
+	members map[string]Locator
+	level   int
+}
+
+// NewHierarchical creates a hierarchical ring. The nodes of groups are the
+// group names, and members holds the Locator of every group. Options,
+// weights and overrides of groups apply to the choice of a group.
+//
+// The groups are placed with a copy of the hash function of groups salted
+// by the level of the ring, one above its deepest nested HierarchicalRing.
+// Groups added later keep that level, so build nested hierarchies from
+// the bottom up.
+func NewHierarchical(groups *HashRing, members map[string]Locator) *HierarchicalRing {
+	copied := make(map[string]Locator, len(members))
+	level := 1
+	for group, member := range members {
+		copied[group] = member
+		if nested, ok := member.(*HierarchicalRing); ok {
+			level = max(level, nested.level+1)
+		}
+	}
+	return &HierarchicalRing{
+		groups:  groups.withSaltedHash(groupHashSalt(level)),
+		members: copied,
+		level:   level,
+	}
+}
+
+func (r *HierarchicalRing) Size() int {
+	return r.groups.Size()
+}
+
+// GetGroup returns the group which owns stringKey.
+func (r *HierarchicalRing) GetGroup(stringKey string) (group string, ok bool) {
+	return r.groups.GetNode(stringKey)
+}
+
+// Group returns the Locator of a group.
+func (r *HierarchicalRing) Group(group string) (member Locator, ok bool) {
+	member, ok = r.members[group]
+	return member, ok
+}
+
+func (r *HierarchicalRing) GetNode(stringKey string) (node string, ok bool) {
+	member, ok := r.member(stringKey)
+	if !ok {
+		return "", false
+	}
+	return member.GetNode(stringKey)
+}
+
+// GetNodes returns the replicas of stringKey inside the group which owns
+// the key.
+func (r *HierarchicalRing) GetNodes(stringKey string, size int) (nodes []string, ok bool) {
+	member, ok := r.member(stringKey)
+	if !ok {
+		return nil, false
+	}
+	return member.GetNodes(stringKey, size)
+}
+
+func (r *HierarchicalRing) member(stringKey string) (Locator, bool) {
+	group, ok := r.GetGroup(stringKey)
+	if !ok {
+		return nil, false
+	}
+	member, ok := r.members[group]
+	return member, ok
+}
+
+func (r *HierarchicalRing) AddGroup(group string, member Locator) *HierarchicalRing {
+	return r.AddWeightedGroup(group, 1, member)
+}
+
+func (r *HierarchicalRing) AddWeightedGroup(group string, weight float64, member Locator) *HierarchicalRing {
+	groups := r.groups.AddFloatWeightedNode(group, weight)
+	if groups == r.groups {
+		return r
+	}
+	return r.derive(groups, group, member)
+}
+
+func (r *HierarchicalRing) RemoveGroup(group string) *HierarchicalRing {
+	groups := r.groups.RemoveNode(group)
+	if groups == r.groups {
+		return r
+	}
+	return r.derive(groups, group, nil)
+}
+
+// UpdateGroup replaces the Locator of an existing group. Keys of other
+// groups are not affected.
+func (r *HierarchicalRing) UpdateGroup(group string, member Locator) *HierarchicalRing {
+	if _, ok := r.members[group]; !ok {
+		return r
+	}
+	return r.derive(r.groups, group, member)
+}
+
+// derive creates a new ring with the given group ring in which the member
+// of group is replaced, or removed if member is nil.
+func (r *HierarchicalRing) derive(groups *HashRing, group string, member Locator) *HierarchicalRing {
+	members := make(map[string]Locator, len(r.members)+1)
+	for eGroup, eMember := range r.members {
+		members[eGroup] = eMember
+	}
+	if member != nil {
+		members[group] = member
+	} else {
+		delete(members, group)
+	}
+	return &HierarchicalRing{
+		groups:  groups,
+		members: members,
+		level:   r.level,
+	}
+}
+
+// withSaltedHash returns a copy of h whose hash function prepends salt to
+// the hashed bytes.
+func (h *HashRing) withSaltedHash(salt string) *HashRing {
+	hashFunc := h.hashFunc
+	salted := *h
+	salted.hashFunc = func(key []byte) HashKey {
+		return hashFunc(append([]byte(salt), key...))
+	}
+	return salted.rebuild(h.nodes, maps.Clone(h.weights))
+}
@@ -0,0 +1,151 @@
+package hashring
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHierarchy() *HierarchicalRing {
+	members := map[string]Locator{
+		"eu": New([]string{"eu-1", "eu-2", "eu-3"}, WithReplicas(40)),
+		"us": New([]string{"us-1", "us-2"}, WithReplicas(40)),
+	}
+	return NewHierarchical(New([]string{"eu", "us"}, WithReplicas(40)), members)
+}
+
+func TestHierarchicalGetNode(t *testing.T) {
+	ring := newTestHierarchy()
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		group, ok := ring.GetGroup(key)
+		assert.True(t, ok)
+		node, ok := ring.GetNode(key)
+		assert.True(t, ok)
+		assert.Contains(t, node, group+"-")
+
+		member, _ := ring.Group(group)
+		expected, _ := member.GetNodes(key, 2)
+		nodes, ok := ring.GetNodes(key, 2)
+		assert.True(t, ok)
+		assert.Equal(t, expected, nodes)
+	}
+}
+
+func TestHierarchicalGroupChanges(t *testing.T) {
+	ring := newTestHierarchy()
+	grown := ring.AddGroup("ap", New([]string{"ap-1"}))
+	assert.Equal(t, 3, grown.Size())
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		before, _ := ring.GetNode(key)
+		after, _ := grown.GetNode(key)
+		if group, _ := grown.GetGroup(key); group != "ap" {
+			assert.Equal(t, before, after, key)
+		}
+	}
+
+	shrunk := grown.RemoveGroup("ap")
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		before, _ := ring.GetNode(key)
+		after, _ := shrunk.GetNode(key)
+		assert.Equal(t, before, after, key)
+	}
+
+	// replacing the members of one group keeps the others intact
+	updated := ring.UpdateGroup("us", New([]string{"us-1", "us-2", "us-3"}))
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if group, _ := ring.GetGroup(key); group == "eu" {
+			before, _ := ring.GetNode(key)
+			after, _ := updated.GetNode(key)
+			assert.Equal(t, before, after, key)
+		}
+	}
+	assert.Same(t, ring, ring.UpdateGroup("ap", New([]string{"ap-1"})))
+}
+
+func TestHierarchicalNested(t *testing.T) {
+	region := newTestHierarchy()
+	ring := NewHierarchical(New([]string{"global"}), map[string]Locator{"global": region})
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		expected, _ := region.GetNode(key)
+		node, ok := ring.GetNode(key)
+		assert.True(t, ok)
+		assert.Equal(t, expected, node)
+	}
+}
+
+func TestHierarchicalNestedBalance(t *testing.T) {
+	regions := make(map[string]Locator)
+	var clusters []string
+	for r := 0; r < 3; r++ {
+		region := fmt.Sprintf("r%d", r)
+		members := make(map[string]Locator)
+		for c := 0; c < 4; c++ {
+			cluster := fmt.Sprintf("%s-c%d", region, c)
+			members[cluster] = New([]string{cluster + "-n0", cluster + "-n1"})
+			clusters = append(clusters, cluster)
+		}
+		regions[region] = NewHierarchical(New(slices.Sorted(maps.Keys(members))), members)
+	}
+	ring := NewHierarchical(New(slices.Sorted(maps.Keys(regions))), regions)
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		node, _ := ring.GetNode(fmt.Sprintf("key%d", i))
+		counts[node[:len(node)-len("-n0")]]++
+	}
+	for _, cluster := range clusters {
+		assert.NotZero(t, counts[cluster], cluster)
+	}
+}
+
+func TestHierarchicalBalance(t *testing.T) {
+	ring := newTestHierarchy()
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		node, _ := ring.GetNode(fmt.Sprintf("key%d", i))
+		counts[node]++
+	}
+	assert.Len(t, counts, 5)
+	for node, count := range counts {
+		assert.Greater(t, count, 500, node)
+	}
+}
+
+func TestHierarchicalGroupOptions(t *testing.T) {
+	members := map[string]Locator{
+		"eu": New([]string{"eu-1"}),
+		"us": New([]string{"us-1"}),
+	}
+	overrides := NewOverrides()
+	overrides.Pin("admin:1:profile", "us")
+	overrides.Pin("admin:2:profile", "eu")
+	groups := New([]string{"eu", "us"}, WithReplicas(40),
+		WithOverrides(overrides), WithKeyExtractor(PrefixExtractor(":", 2)))
+	ring := NewHierarchical(groups, members)
+
+	// the extractor and the overrides see the original keys
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		group, _ := ring.GetGroup(fmt.Sprintf("user:%d:profile", i))
+		counts[group]++
+		other, _ := ring.GetGroup(fmt.Sprintf("user:%d:settings", i))
+		assert.Equal(t, group, other, i)
+	}
+	assert.Len(t, counts, 2)
+	group, _ := ring.GetGroup("admin:1:profile")
+	assert.Equal(t, "us", group)
+	group, _ = ring.GetGroup("admin:2:profile")
+	assert.Equal(t, "eu", group)
+}
@@ -0,0 +1,67 @@
+package hashring
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+)
+
+// ReplicaSelector picks the node which serves a request for a hot key out
+// of the key's replicas, given in the order returned by GetNodes.
+// ReplicaSelector must be safe for concurrent use.
+type ReplicaSelector func(key string, replicas []string) string
+
+// RandomSelector spreads requests uniformly over the replicas.
+func RandomSelector() ReplicaSelector {
+	return func(key string, replicas []string) string {
+		return replicas[rand.IntN(len(replicas))]
+	}
+}
+
+// RoundRobinSelector cycles through the replicas of every key.
+func RoundRobinSelector() ReplicaSelector {
+	var counters sync.Map
+	return func(key string, replicas []string) string {
+		counter, ok := counters.Load(key)
+		if !ok {
+			counter, _ = counters.LoadOrStore(key, new(atomic.Uint64))
+		}
+		n := counter.(*atomic.Uint64).Add(1) - 1
+		return replicas[n%uint64(len(replicas))]
+	}
+}
+
+// LeastLoadedSelector picks the replica with the lowest load. Ties are
+// resolved in ring order.
+func LeastLoadedSelector(load func(node string) float64) ReplicaSelector {
+	return func(key string, replicas []string) string {
+		best := replicas[0]
+		bestLoad := load(best)
+		for _, node := range replicas[1:] {
+			if nodeLoad := load(node); nodeLoad < bestLoad {
+				best, bestLoad = node, nodeLoad
+			}
+		}
+		return best
+	}
+}
+
+type hotKeys struct {
+	fanout   int
+	selector ReplicaSelector
+	keys     map[string]bool
+}
+
+// hotNode returns the node selected to serve stringKey if it is a hot key.
+func (h *HashRing) hotNode(stringKey string) (node string, ok bool) {
+	if h.hotKeys == nil || !h.hotKeys.keys[stringKey] {
+		return "", false
+	}
+
+	fanout := min(h.hotKeys.fanout, len(h.nodes))
+	replicas, _, ok := h.walk(stringKey, fanout, nil)
+	if !ok {
+		return "", false
+	}
+	return h.hotKeys.selector(stringKey, replicas), true
+}
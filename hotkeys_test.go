@@ -0,0 +1,88 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHotKeysRoundRobin(t *testing.T) {
+	nodes := []string{"a", "b", "c"}
+	ring := New(nodes, WithHotKeys(2, RoundRobinSelector(), "test"))
+
+	// "test" is stored on [a c], see expectNodeRangesABC
+	served := make([]string, 0)
+	for i := 0; i < 4; i++ {
+		node, ok := ring.GetNode("test")
+		assert.True(t, ok)
+		served = append(served, node)
+	}
+	assert.Equal(t, []string{"a", "c", "a", "c"}, served)
+
+	// keys which are not hot keep their owner
+	for i := 0; i < 4; i++ {
+		node, _ := ring.GetNode("test1")
+		assert.Equal(t, "b", node)
+	}
+}
+
+func TestHotKeysRandom(t *testing.T) {
+	ring := New([]string{"a", "b", "c"}, WithHotKeys(2, RandomSelector(), "test"))
+
+	served := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		node, _ := ring.GetNode("test")
+		served[node] = true
+	}
+	assert.Equal(t, map[string]bool{"a": true, "c": true}, served)
+
+	ring = New([]string{"a", "b", "c"}, WithHotKeys(2, nil, "test"))
+	served = make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		node, _ := ring.GetNode("test")
+		served[node] = true
+	}
+	assert.Equal(t, map[string]bool{"a": true, "c": true}, served)
+}
+
+func TestHotKeysLeastLoaded(t *testing.T) {
+	load := map[string]float64{"a": 0.9, "b": 0.1, "c": 0.5}
+	selector := LeastLoadedSelector(func(node string) float64 { return load[node] })
+
+	ring := New([]string{"a", "b", "c"}, WithHotKeys(2, selector, "test"))
+	node, _ := ring.GetNode("test")
+	assert.Equal(t, "c", node)
+
+	// fanout is capped by the number of nodes and survives updates
+	ring = New([]string{"a", "c"}, WithHotKeys(5, selector, "test"))
+	ring = ring.AddNode("b")
+	node, _ = ring.GetNode("test")
+	assert.Equal(t, "b", node)
+}
+
+func TestOwner(t *testing.T) {
+	r := &recorder{}
+	overrides := NewOverrides()
+	overrides.Pin("pinned", "b")
+	ring := New([]string{"a", "b", "c"},
+		WithHotKeys(2, RoundRobinSelector(), "test"),
+		WithOverrides(overrides),
+		WithObserver(r))
+
+	// "test" is owned by a, see expectNodeRangesABC
+	for i := 0; i < 4; i++ {
+		node, ok := ring.Owner("test")
+		assert.True(t, ok)
+		assert.Equal(t, "a", node)
+	}
+	node, _ := ring.Owner("pinned")
+	assert.Equal(t, "b", node)
+	assert.Empty(t, r.lookups)
+
+	// the round robin counter didn't advance
+	node, _ = ring.GetNode("test")
+	assert.Equal(t, "a", node)
+
+	_, ok := New(nil).Owner("test")
+	assert.False(t, ok)
+}
@@ -0,0 +1,252 @@
+// Package httpbalancer provides a reverse proxy which routes requests to
+// backends chosen by a consistent hashing ring, so requests with the same
+// key keep hitting the same backend and its caches:
+//
+//	ring := hashring.NewAtomicRing(hashring.New(
+//		[]string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"},
+//		hashring.WithReplicas(160),
+//	))
+//	http.ListenAndServe(":80", httpbalancer.New(ring, httpbalancer.HeaderKey("X-User-Id")))
+package httpbalancer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/serialx/hashring"
+)
+
+// KeyFunc extracts the routing key of a request.
+type KeyFunc func(r *http.Request) (key string, ok bool)
+
+// HeaderKey uses the value of a request header as the key.
+func HeaderKey(name string) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		value := r.Header.Get(name)
+		return value, value != ""
+	}
+}
+
+// CookieKey uses the value of a cookie as the key.
+func CookieKey(name string) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		cookie, err := r.Cookie(name)
+		if err != nil || cookie.Value == "" {
+			return "", false
+		}
+		return cookie.Value, true
+	}
+}
+
+// QueryKey uses the value of a query parameter as the key.
+func QueryKey(name string) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		value := r.URL.Query().Get(name)
+		return value, value != ""
+	}
+}
+
+// PathSegmentKey uses the index-th segment of the URL path as the key,
+// e.g. index 1 extracts "42" from "/users/42/avatar".
+func PathSegmentKey(index int) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if index < 0 || index >= len(segments) || segments[index] == "" {
+			return "", false
+		}
+		return segments[index], true
+	}
+}
+
+// Balancer is an http.Handler which proxies requests to the node of the
+// ring which owns the request key. Nodes are backend base URLs such as
+// "http://10.0.0.1:8080"; "10.0.0.1:8080" stands for "http://10.0.0.1:8080".
+//
+// If a backend can't be reached, the request is retried on the next nodes
+// returned by GetNodes. Requests with a body are only retried if the body
+// can be replayed, see http.Request.GetBody.
+type Balancer struct {
+	Ring *hashring.AtomicRing
+	Key  KeyFunc
+	// Retries is the number of further backends tried after the first
+	// one fails.
+	Retries int
+	// Transport defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+	// ErrorHandler, if set, handles requests which could not be proxied.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+	proxyOnce sync.Once
+	proxy     *httputil.ReverseProxy
+}
+
+var ErrNoBackends = errors.New("no backends available")
+
+// New creates a balancer which retries requests on one other backend.
+// Requests for which key finds no key are routed by their URL path.
+func New(ring *hashring.AtomicRing, key KeyFunc) *Balancer {
+	return &Balancer{
+		Ring:    ring,
+		Key:     key,
+		Retries: 1,
+	}
+}
+
+type backendsKey struct{}
+
+// backends are the candidates for a request in the order of GetNodes,
+// together with the inbound URL they are joined with.
+type backends struct {
+	inbound *url.URL
+	targets []*url.URL
+}
+
+func (b *Balancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, ok := b.Key(r)
+	if !ok {
+		key = r.URL.Path
+	}
+
+	nodes, _ := b.Ring.Load().GetNodesFunc(key, b.Retries+1, nil)
+	targets := make([]*url.URL, 0, len(nodes))
+	for _, node := range nodes {
+		if !strings.Contains(node, "://") {
+			node = "http://" + node
+		}
+		target, err := url.Parse(node)
+		if err != nil {
+			continue
+		}
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		b.handleError(w, r, ErrNoBackends)
+		return
+	}
+
+	candidates := &backends{inbound: r.URL, targets: targets}
+	r = r.WithContext(context.WithValue(r.Context(), backendsKey{}, candidates))
+	b.reverseProxy().ServeHTTP(w, r)
+}
+
+func (b *Balancer) reverseProxy() *httputil.ReverseProxy {
+	b.proxyOnce.Do(func() {
+		b.proxy = &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.SetXForwarded()
+				pr.Out.Host = ""
+			},
+			Transport:    &retryTransport{balancer: b},
+			ErrorHandler: b.handleError,
+		}
+	})
+	return b.proxy
+}
+
+func (b *Balancer) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if b.ErrorHandler != nil {
+		b.ErrorHandler(w, r, err)
+		return
+	}
+	if errors.Is(err, ErrNoBackends) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+// retryTransport sends a request to its backends one after another until
+// one of them responds.
+type retryTransport struct {
+	balancer *Balancer
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.balancer.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	candidates := req.Context().Value(backendsKey{}).(*backends)
+
+	var lastErr error
+	for i, target := range candidates.targets {
+		attempt := req
+		if i > 0 {
+			if !replayable(req) {
+				break
+			}
+			attempt = req.Clone(req.Context())
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attempt.Body = body
+			}
+		}
+		rewriteURL(attempt.URL, target, candidates.inbound)
+
+		resp, err := transport.RoundTrip(attempt)
+		if err == nil {
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewriteURL routes out to target like httputil.ProxyRequest.SetURL does.
+func rewriteURL(out, target, inbound *url.URL) {
+	out.Scheme = target.Scheme
+	out.Host = target.Host
+	out.Path, out.RawPath = joinURLPath(target, inbound)
+	if target.RawQuery == "" || inbound.RawQuery == "" {
+		out.RawQuery = target.RawQuery + inbound.RawQuery
+	} else {
+		out.RawQuery = target.RawQuery + "&" + inbound.RawQuery
+	}
+}
+
+// joinURLPath joins the paths of a and b like singleJoiningSlash, keeping
+// their escaping so that e.g. %2F doesn't turn into a path separator.
+func joinURLPath(a, b *url.URL) (path, rawpath string) {
+	if a.RawPath == "" && b.RawPath == "" {
+		return singleJoiningSlash(a.Path, b.Path), ""
+	}
+	apath := a.EscapedPath()
+	bpath := b.EscapedPath()
+
+	aslash := strings.HasSuffix(apath, "/")
+	bslash := strings.HasPrefix(bpath, "/")
+	switch {
+	case aslash && bslash:
+		return a.Path + b.Path[1:], apath + bpath[1:]
+	case !aslash && !bslash:
+		return a.Path + "/" + b.Path, apath + "/" + bpath
+	}
+	return a.Path + b.Path, apath + bpath
+}
+
+func singleJoiningSlash(a, b string) string {
+	aslash := strings.HasSuffix(a, "/")
+	bslash := strings.HasPrefix(b, "/")
+	switch {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash:
+		return a + "/" + b
+	}
+	return a + b
+}
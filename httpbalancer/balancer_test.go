@@ -0,0 +1,162 @@
+package httpbalancer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/serialx/hashring"
+	"github.com/stretchr/testify/assert"
+)
+
+func startBackends(t *testing.T, n int) []*httptest.Server {
+	servers := make([]*httptest.Server, 0, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("backend%d", i)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			fmt.Fprintf(w, "%s %s?%s %s", name, r.URL.Path, r.URL.RawQuery, body)
+		}))
+		t.Cleanup(server.Close)
+		servers = append(servers, server)
+	}
+	return servers
+}
+
+func get(t *testing.T, handler http.Handler, req *http.Request) (int, string) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder.Code, recorder.Body.String()
+}
+
+func backendName(body string) string {
+	name, _, _ := strings.Cut(body, " ")
+	return name
+}
+
+func TestBalancerSticky(t *testing.T) {
+	servers := startBackends(t, 3)
+	nodes := make([]string, 0)
+	for _, server := range servers {
+		nodes = append(nodes, server.URL)
+	}
+	balancer := New(hashring.NewAtomicRing(hashring.New(nodes, hashring.WithReplicas(40))), HeaderKey("X-User"))
+
+	served := make(map[string]bool)
+	for i := 0; i < 30; i++ {
+		user := fmt.Sprintf("user%d", i)
+		first := ""
+		for j := 0; j < 3; j++ {
+			req := httptest.NewRequest("GET", fmt.Sprintf("/render/%d?size=%d", j, j), nil)
+			req.Header.Set("X-User", user)
+			code, body := get(t, balancer, req)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Contains(t, body, fmt.Sprintf(" /render/%d?size=%d ", j, j))
+			if j == 0 {
+				first = backendName(body)
+			}
+			assert.Equal(t, first, backendName(body), user)
+		}
+		served[first] = true
+	}
+	assert.Len(t, served, 3)
+}
+
+func TestBalancerRetry(t *testing.T) {
+	servers := startBackends(t, 2)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	ring := hashring.NewAtomicRing(hashring.New([]string{down.URL, servers[0].URL}, hashring.WithReplicas(100)))
+	balancer := New(ring, QueryKey("id"))
+
+	// the ids of a key of the down backend and of one of the other
+	var downID, upID string
+	for i := 0; downID == "" || upID == ""; i++ {
+		id := strconv.Itoa(i)
+		node, _ := ring.GetNode(id)
+		switch {
+		case node == down.URL && downID == "":
+			downID = id
+		case node != down.URL && upID == "":
+			upID = id
+		}
+	}
+
+	for _, id := range []string{downID, upID} {
+		code, body := get(t, balancer, httptest.NewRequest("GET", "/?id="+id, nil))
+		assert.Equal(t, http.StatusOK, code, id)
+		assert.Equal(t, "backend0", backendName(body), id)
+	}
+
+	// requests with a body which can't be replayed are not retried
+	code, _ := get(t, balancer, httptest.NewRequest("POST", "/?id="+downID, strings.NewReader("payload")))
+	assert.Equal(t, http.StatusBadGateway, code)
+	code, _ = get(t, balancer, httptest.NewRequest("POST", "/?id="+upID, strings.NewReader("payload")))
+	assert.Equal(t, http.StatusOK, code)
+
+	balancer.Retries = 0
+	code, _ = get(t, balancer, httptest.NewRequest("GET", "/?id="+downID, nil))
+	assert.Equal(t, http.StatusBadGateway, code)
+}
+
+func TestBalancerEscapedPath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.RequestURI)
+	}))
+	t.Cleanup(server.Close)
+
+	for target, expected := range map[string]string{
+		server.URL:             "/files/a%2Fb?x=1",
+		server.URL + "/api/":   "/api/files/a%2Fb?x=1",
+		server.URL + "/a%2Fb":  "/a%2Fb/files/a%2Fb?x=1",
+		server.URL + "/plain/": "/plain/files/a%2Fb?x=1",
+	} {
+		balancer := New(hashring.NewAtomicRing(hashring.New([]string{target})), HeaderKey("X-User"))
+		code, body := get(t, balancer, httptest.NewRequest("GET", "/files/a%2Fb?x=1", nil))
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, expected, body, target)
+	}
+
+	balancer := New(hashring.NewAtomicRing(hashring.New([]string{server.URL + "/api"})), HeaderKey("X-User"))
+	_, body := get(t, balancer, httptest.NewRequest("GET", "/files/a%20b", nil))
+	assert.Equal(t, "/api/files/a%20b", body)
+}
+
+func TestBalancerNoBackends(t *testing.T) {
+	balancer := New(hashring.NewAtomicRing(hashring.New(nil)), HeaderKey("X-User"))
+	code, _ := get(t, balancer, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
+
+func TestKeyFuncs(t *testing.T) {
+	req := httptest.NewRequest("GET", "/users/42/avatar?tenant=acme", nil)
+	req.Header.Set("X-User", "alice")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s3"})
+
+	for expected, keyFunc := range map[string]KeyFunc{
+		"alice": HeaderKey("X-User"),
+		"s3":    CookieKey("session"),
+		"acme":  QueryKey("tenant"),
+		"42":    PathSegmentKey(1),
+	} {
+		key, ok := keyFunc(req)
+		assert.True(t, ok, expected)
+		assert.Equal(t, expected, key)
+	}
+
+	for _, keyFunc := range []KeyFunc{
+		HeaderKey("X-Missing"),
+		CookieKey("missing"),
+		QueryKey("missing"),
+		PathSegmentKey(3),
+		PathSegmentKey(-1),
+	} {
+		_, ok := keyFunc(req)
+		assert.False(t, ok)
+	}
+}
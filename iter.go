@@ -0,0 +1,55 @@
+package hashring
+
+import "iter"
+
+// Point is a virtual point of a node on the ring.
+type Point struct {
+	// Pos is the index of the point in clockwise order, as returned by
+	// GetNodePos.
+	Pos   int
+	Token HashKey
+	Node  string
+}
+
+// All yields the tokens of the ring together with the nodes owning them
+// in clockwise order.
+func (h *HashRing) All() iter.Seq2[HashKey, string] {
+	return func(yield func(HashKey, string) bool) {
+		for _, key := range h.sortedKeys {
+			if !yield(key, h.ring[key]) {
+				return
+			}
+		}
+	}
+}
+
+// Points yields the virtual points of the ring in clockwise order.
+func (h *HashRing) Points() iter.Seq[Point] {
+	return func(yield func(Point) bool) {
+		for pos, key := range h.sortedKeys {
+			if !yield(Point{Pos: pos, Token: key, Node: h.ring[key]}) {
+				return
+			}
+		}
+	}
+}
+
+// WalkFrom yields every virtual point of the ring once in clockwise order,
+// starting with the point which owns stringKey. Unlike GetNodes it does
+// not skip points of nodes which were already seen, so it can be used to
+// implement custom replica policies.
+func (h *HashRing) WalkFrom(stringKey string) iter.Seq[Point] {
+	return func(yield func(Point) bool) {
+		start, ok := h.GetNodePos(stringKey)
+		if !ok {
+			return
+		}
+		for i := start; i < start+len(h.sortedKeys); i++ {
+			pos := i % len(h.sortedKeys)
+			key := h.sortedKeys[pos]
+			if !yield(Point{Pos: pos, Token: key, Node: h.ring[key]}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package hashring
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAll(t *testing.T) {
+	ring := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1})
+
+	tokens := make([]HashKey, 0)
+	counts := make(map[string]int)
+	for token, node := range ring.All() {
+		tokens = append(tokens, token)
+		counts[node]++
+	}
+	assert.True(t, sort.IsSorted(HashKeyOrder(tokens)))
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 1}, counts)
+
+	for range ring.All() {
+		break
+	}
+}
+
+func TestPoints(t *testing.T) {
+	ring := New([]string{"a", "b", "c"}, WithReplicas(3))
+
+	pos := 0
+	for point := range ring.Points() {
+		assert.Equal(t, pos, point.Pos)
+		assert.Equal(t, ring.sortedKeys[pos], point.Token)
+		assert.Equal(t, ring.ring[point.Token], point.Node)
+		pos++
+	}
+	assert.Equal(t, 9, pos)
+}
+
+func TestWalkFrom(t *testing.T) {
+	ring := New([]string{"a", "b", "c"}, WithReplicas(3))
+
+	for _, key := range []string{"test", "test1", "test2", "aaaa"} {
+		start, _ := ring.GetNodePos(key)
+		owner, _ := ring.GetNode(key)
+
+		points := make([]Point, 0)
+		for point := range ring.WalkFrom(key) {
+			points = append(points, point)
+		}
+		assert.Len(t, points, 9)
+		assert.Equal(t, start, points[0].Pos)
+		assert.Equal(t, owner, points[0].Node)
+		for i, point := range points {
+			assert.Equal(t, (start+i)%9, point.Pos)
+		}
+
+		// deduplicating the walk gives GetNodes
+		seen := make(map[string]bool)
+		distinct := make([]string, 0)
+		for point := range ring.WalkFrom(key) {
+			if !seen[point.Node] {
+				seen[point.Node] = true
+				distinct = append(distinct, point.Node)
+			}
+		}
+		nodes, _ := ring.GetNodes(key, 3)
+		assert.Equal(t, nodes, distinct)
+	}
+
+	for range New(nil).WalkFrom("test") {
+		t.Error("empty ring yielded a point")
+	}
+}
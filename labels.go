@@ -0,0 +1,98 @@
+package hashring
+
+import (
+	"maps"
+	"sort"
+)
+
+// Labels carry metadata of a node such as its zone, version, capacity or
+// address, so callers don't have to encode it in the node name.
+type Labels map[string]string
+
+// Matches reports whether l has every label of selector with the same value.
+func (l Labels) Matches(selector Labels) bool {
+	for name, value := range selector {
+		if actual, ok := l[name]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Labels returns a copy of the labels of node.
+func (h *HashRing) Labels(node string) Labels {
+	return maps.Clone(h.labels[node])
+}
+
+// SetLabels returns a ring in which node carries the given labels. The
+// placement of keys is not affected and the ring is not rebuilt.
+func (h *HashRing) SetLabels(node string, labels Labels) *HashRing {
+	if _, ok := h.weights[node]; !ok {
+		return h
+	}
+
+	copied := make(map[string]Labels, len(h.labels)+1)
+	for eNode, eLabels := range h.labels {
+		copied[eNode] = eLabels
+	}
+	copied[node] = maps.Clone(labels)
+
+	hashRing := *h
+	hashRing.labels = copied
+	return &hashRing
+}
+
+func (h *HashRing) AddLabeledNode(node string, weight float64, labels Labels) *HashRing {
+	hashRing := h.AddFloatWeightedNode(node, weight)
+	if hashRing == h {
+		return h
+	}
+	return hashRing.SetLabels(node, labels)
+}
+
+// MatchLabels returns a predicate accepting the nodes whose labels match
+// selector. It can be passed to GetNodesFunc, e.g. to only return replicas
+// in a given zone.
+func (h *HashRing) MatchLabels(selector Labels) func(node string) bool {
+	return func(node string) bool {
+		return h.labels[node].Matches(selector)
+	}
+}
+
+// NodesWithLabels returns the sorted nodes whose labels match selector.
+func (h *HashRing) NodesWithLabels(selector Labels) []string {
+	nodes := make([]string, 0)
+	for _, node := range h.nodes {
+		if h.labels[node].Matches(selector) {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// GroupByLabel returns the sorted nodes of the ring grouped by the value of
+// the label name. Nodes without the label are left out.
+func (h *HashRing) GroupByLabel(name string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, node := range h.nodes {
+		if value, ok := h.labels[node][name]; ok {
+			groups[value] = append(groups[value], node)
+		}
+	}
+	for _, nodes := range groups {
+		sort.Strings(nodes)
+	}
+	return groups
+}
+
+// memberLabels returns the labels of the given members of the ring.
+func memberLabels(labels map[string]Labels, weights map[string]float64) map[string]Labels {
+	result := make(map[string]Labels, len(labels))
+	for node, nodeLabels := range labels {
+		if _, ok := weights[node]; ok {
+			result[node] = nodeLabels
+		}
+	}
+	return result
+}
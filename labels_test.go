@@ -0,0 +1,59 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLabeledRing() *HashRing {
+	return New([]string{"a", "b", "c"}, WithLabels(map[string]Labels{
+		"a": {"zone": "eu-1", "version": "2"},
+		"b": {"zone": "eu-2", "version": "2"},
+		"c": {"zone": "eu-1", "version": "1"},
+		"x": {"zone": "eu-3"},
+	}))
+}
+
+func TestLabels(t *testing.T) {
+	ring := newLabeledRing()
+
+	assert.Equal(t, Labels{"zone": "eu-1", "version": "2"}, ring.Labels("a"))
+	assert.Nil(t, ring.Labels("x"))
+
+	labels := ring.Labels("a")
+	labels["zone"] = "us-1"
+	assert.Equal(t, "eu-1", ring.Labels("a")["zone"])
+
+	updated := ring.SetLabels("a", Labels{"zone": "us-1"})
+	assert.Equal(t, Labels{"zone": "us-1"}, updated.Labels("a"))
+	assert.Equal(t, "eu-1", ring.Labels("a")["zone"])
+	assert.Equal(t, ring.sortedKeys, updated.sortedKeys)
+	assert.Same(t, ring, ring.SetLabels("x", Labels{"zone": "us-1"}))
+
+	// labels follow membership changes
+	ring = ring.AddLabeledNode("d", 1, Labels{"zone": "eu-3"})
+	assert.Equal(t, Labels{"zone": "eu-3"}, ring.Labels("d"))
+	assert.Equal(t, Labels{"zone": "eu-2", "version": "2"}, ring.Labels("b"))
+	ring = ring.RemoveNode("b")
+	assert.Nil(t, ring.Labels("b"))
+	ring = ring.AddNode("b")
+	assert.Nil(t, ring.Labels("b"))
+}
+
+func TestLabelSelection(t *testing.T) {
+	ring := newLabeledRing()
+
+	assert.Equal(t, []string{"a", "c"}, ring.NodesWithLabels(Labels{"zone": "eu-1"}))
+	assert.Equal(t, []string{"a"}, ring.NodesWithLabels(Labels{"zone": "eu-1", "version": "2"}))
+	assert.Equal(t, []string{"a", "b", "c"}, ring.NodesWithLabels(nil))
+	assert.Equal(t, map[string][]string{
+		"eu-1": {"a", "c"},
+		"eu-2": {"b"},
+	}, ring.GroupByLabel("zone"))
+
+	// "test" is stored on [a c b]
+	nodes, ok := ring.GetNodesFunc("test", 2, ring.MatchLabels(Labels{"version": "2"}))
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", "b"}, nodes)
+}
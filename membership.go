@@ -0,0 +1,185 @@
+package hashring
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+type EventType int
+
+const (
+	NodeAdded EventType = iota
+	NodeRemoved
+	NodeReweighted
+)
+
+func (t EventType) String() string {
+	switch t {
+	case NodeAdded:
+		return "added"
+	case NodeRemoved:
+		return "removed"
+	case NodeReweighted:
+		return "reweighted"
+	}
+	return "unknown"
+}
+
+// Event is a change of the membership of a ring. Weight is ignored for
+// NodeRemoved.
+type Event struct {
+	Type   EventType
+	Node   string
+	Weight float64
+}
+
+// MembershipSource emits the membership changes of a ring, e.g. from
+// Consul, etcd or Kubernetes EndpointSlices.
+type MembershipSource interface {
+	// Watch sends events until ctx is done or the source fails.
+	Watch(ctx context.Context, events chan<- Event) error
+}
+
+// StaticSource is a MembershipSource which adds a fixed list of nodes.
+type StaticSource map[string]float64
+
+func (s StaticSource) Watch(ctx context.Context, events chan<- Event) error {
+	for node, weight := range s {
+		select {
+		case events <- Event{Type: NodeAdded, Node: node, Weight: weight}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// WeightEvents returns the events which turn the oldWeights membership into
+// the newWeights one.
+func WeightEvents(oldWeights, newWeights map[string]float64) []Event {
+	events := make([]Event, 0)
+	for node, weight := range newWeights {
+		oldWeight, ok := oldWeights[node]
+		switch {
+		case !ok:
+			events = append(events, Event{Type: NodeAdded, Node: node, Weight: weight})
+		case oldWeight != weight:
+			events = append(events, Event{Type: NodeReweighted, Node: node, Weight: weight})
+		}
+	}
+	for node := range oldWeights {
+		if _, ok := newWeights[node]; !ok {
+			events = append(events, Event{Type: NodeRemoved, Node: node})
+		}
+	}
+	return events
+}
+
+// RingChange describes a change applied by a RingManager.
+type RingChange struct {
+	Event Event
+	Old   *HashRing
+	New   *HashRing
+	// Moved are the ranges of the hash space which changed owners.
+	Moved []KeyRange
+}
+
+// RingManager applies membership events to a ring and notifies its
+// subscribers about every change.
+type RingManager struct {
+	mu          sync.Mutex
+	ring        *AtomicRing
+	subscribers []subscription
+	nextID      int
+}
+
+type subscription struct {
+	id     int
+	notify func(change RingChange)
+}
+
+func NewRingManager(ring *AtomicRing) *RingManager {
+	return &RingManager{ring: ring}
+}
+
+func (m *RingManager) Ring() *AtomicRing {
+	return m.ring
+}
+
+// Subscribe registers a function which is called with every change
+// applied to the ring, in order. It must not call Apply. The returned
+// function cancels the subscription.
+func (m *RingManager) Subscribe(subscriber func(change RingChange)) (cancel func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	id := m.nextID
+	m.subscribers = append(m.subscribers, subscription{id: id, notify: subscriber})
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.subscribers = slices.DeleteFunc(m.subscribers, func(s subscription) bool {
+			return s.id == id
+		})
+	}
+}
+
+// Apply applies event to the ring with AddWeightedNode, RemoveNode or
+// UpdateWeightedNode. It reports whether the ring changed; events which
+// don't apply, like adding an existing node, are ignored.
+func (m *RingManager) Apply(event Event) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldRing, newRing := m.ring.Update(func(ring *HashRing) *HashRing {
+		switch event.Type {
+		case NodeAdded:
+			return ring.AddFloatWeightedNode(event.Node, event.Weight)
+		case NodeRemoved:
+			return ring.RemoveNode(event.Node)
+		case NodeReweighted:
+			return ring.UpdateFloatWeightedNode(event.Node, event.Weight)
+		}
+		return ring
+	})
+	if oldRing == newRing {
+		return false
+	}
+
+	if len(m.subscribers) > 0 {
+		change := RingChange{
+			Event: event,
+			Old:   oldRing,
+			New:   newRing,
+			Moved: DiffRanges(oldRing, newRing),
+		}
+		for _, subscriber := range m.subscribers {
+			subscriber.notify(change)
+		}
+	}
+	return true
+}
+
+// Run applies the events of source until ctx is done or the source
+// stops, and returns the error of the source.
+func (m *RingManager) Run(ctx context.Context, source MembershipSource) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan Event)
+	done := make(chan error, 1)
+	go func() {
+		done <- source.Watch(ctx, events)
+	}()
+
+	for {
+		select {
+		case event := <-events:
+			m.Apply(event)
+		case err := <-done:
+			return err
+		}
+	}
+}
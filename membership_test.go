@@ -0,0 +1,90 @@
+package hashring
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSource struct {
+	events chan Event
+}
+
+func (s *fakeSource) Watch(ctx context.Context, events chan<- Event) error {
+	for {
+		select {
+		case event, ok := <-s.events:
+			if !ok {
+				return nil
+			}
+			events <- event
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestRingManager(t *testing.T) {
+	manager := NewRingManager(NewAtomicRing(New(nil, WithReplicas(10))))
+
+	changes := make([]RingChange, 0)
+	manager.Subscribe(func(change RingChange) { changes = append(changes, change) })
+
+	source := &fakeSource{events: make(chan Event, 10)}
+	source.events <- Event{Type: NodeAdded, Node: "a", Weight: 1}
+	source.events <- Event{Type: NodeAdded, Node: "b", Weight: 1}
+	source.events <- Event{Type: NodeAdded, Node: "b", Weight: 1}
+	source.events <- Event{Type: NodeReweighted, Node: "b", Weight: 2}
+	source.events <- Event{Type: NodeRemoved, Node: "a"}
+	source.events <- Event{Type: NodeRemoved, Node: "x"}
+	close(source.events)
+
+	assert.NoError(t, manager.Run(context.Background(), source))
+
+	types := make([]EventType, 0)
+	for i, change := range changes {
+		types = append(types, change.Event.Type)
+		assert.NotEmpty(t, change.Moved)
+		if i > 0 {
+			assert.Same(t, changes[i-1].New, change.Old)
+		}
+	}
+	assert.Equal(t, []EventType{NodeAdded, NodeAdded, NodeReweighted, NodeRemoved}, types)
+	assert.Same(t, manager.Ring().Load(), changes[3].New)
+
+	weight, ok := manager.Ring().Load().Weight("b")
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, weight)
+	assert.Equal(t, 1, manager.Ring().Load().Size())
+
+	cancel := manager.Subscribe(func(change RingChange) { t.Error("canceled subscriber was notified") })
+	cancel()
+	assert.True(t, manager.Apply(Event{Type: NodeAdded, Node: "c", Weight: 1}))
+	assert.Len(t, changes, 5)
+}
+
+func TestRingManagerStatic(t *testing.T) {
+	manager := NewRingManager(NewAtomicRing(New(nil)))
+	source := StaticSource{"a": 1, "b": 2}
+
+	assert.NoError(t, manager.Run(context.Background(), source))
+	assert.Equal(t, 2, manager.Ring().Load().Size())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := manager.Run(ctx, &fakeSource{events: make(chan Event)})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestWeightEvents(t *testing.T) {
+	events := WeightEvents(
+		map[string]float64{"a": 1, "b": 1, "c": 1},
+		map[string]float64{"a": 1, "b": 2, "d": 1},
+	)
+	assert.ElementsMatch(t, []Event{
+		{Type: NodeReweighted, Node: "b", Weight: 2},
+		{Type: NodeAdded, Node: "d", Weight: 1},
+		{Type: NodeRemoved, Node: "c"},
+	}, events)
+}
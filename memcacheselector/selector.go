@@ -0,0 +1,168 @@
+// Package memcacheselector implements the ServerSelector of
+// github.com/bradfitz/gomemcache on top of a consistent hashing ring,
+// replacing the modulo based memcache.ServerList:
+//
+//	selector, err := memcacheselector.New(
+//		[]string{"192.168.0.246:11212", "192.168.0.247:11212"},
+//		hashring.WithReplicas(160),
+//	)
+//	client := memcache.NewFromSelector(selector)
+package memcacheselector
+
+import (
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/serialx/hashring"
+)
+
+// Selector picks memcached servers with a hashring.HashRing. Nodes of the
+// ring are server addresses in the form accepted by
+// memcache.ServerList.SetServers: "host:port" or the path of a unix socket.
+//
+// The ring is held by a hashring.AtomicRing, so it can be updated while the
+// selector is in use, with SetServers or by any source sharing the
+// AtomicRing, e.g. a hashring.FileSource.
+type Selector struct {
+	ring *hashring.AtomicRing
+
+	mu    sync.RWMutex
+	addrs map[string]net.Addr
+}
+
+var _ memcache.ServerSelector = (*Selector)(nil)
+
+// New creates a selector for the given servers, each with weight 1.
+func New(servers []string, opts ...hashring.Option) (*Selector, error) {
+	addrs, err := resolveAll(servers)
+	if err != nil {
+		return nil, err
+	}
+	s := NewFromRing(hashring.NewAtomicRing(hashring.New(servers, opts...)))
+	s.addrs = addrs
+	return s, nil
+}
+
+// NewFromRing creates a selector which picks servers with ring.
+func NewFromRing(ring *hashring.AtomicRing) *Selector {
+	return &Selector{
+		ring:  ring,
+		addrs: make(map[string]net.Addr),
+	}
+}
+
+func (s *Selector) Ring() *hashring.AtomicRing {
+	return s.ring
+}
+
+// SetServers replaces the servers of the ring, each with weight 1. The
+// ring keeps its options and is only rebuilt if the servers changed. If
+// any of the servers can't be resolved, the ring is left alone.
+func (s *Selector) SetServers(servers ...string) error {
+	weights := make(map[string]float64, len(servers))
+	for _, server := range servers {
+		weights[server] = 1
+	}
+	return s.SetWeightedServers(weights)
+}
+
+// SetWeightedServers works like SetServers with the given server weights.
+func (s *Selector) SetWeightedServers(weights map[string]float64) error {
+	servers := make([]string, 0, len(weights))
+	for server := range weights {
+		servers = append(servers, server)
+	}
+	addrs, err := resolveAll(servers)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.addrs = addrs
+	s.mu.Unlock()
+	s.ring.UpdateWithFloatWeights(weights)
+	return nil
+}
+
+func (s *Selector) PickServer(key string) (net.Addr, error) {
+	node, ok := s.ring.GetNode(key)
+	if !ok {
+		return nil, memcache.ErrNoServers
+	}
+	return s.addr(node)
+}
+
+// Each calls f with the address of every server of the ring, in the
+// order of their names.
+func (s *Selector) Each(f func(net.Addr) error) error {
+	weights := s.ring.Load().Weights()
+	s.prune(weights)
+	for _, node := range slices.Sorted(maps.Keys(weights)) {
+		addr, err := s.addr(node)
+		if err != nil {
+			return err
+		}
+		if err := f(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addr returns the address of node, resolving and caching it the first
+// time a node is seen, which happens when the ring was updated through
+// the AtomicRing rather than SetServers. The addresses of nodes which
+// left the ring are dropped then.
+func (s *Selector) addr(node string) (net.Addr, error) {
+	s.mu.RLock()
+	addr, ok := s.addrs[node]
+	s.mu.RUnlock()
+	if ok {
+		return addr, nil
+	}
+
+	addr, err := resolve(node)
+	if err != nil {
+		return nil, err
+	}
+	s.prune(s.ring.Load().Weights())
+	s.mu.Lock()
+	s.addrs[node] = addr
+	s.mu.Unlock()
+	return addr, nil
+}
+
+// prune drops the cached addresses of nodes which aren't in weights.
+func (s *Selector) prune(weights map[string]float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for node := range s.addrs {
+		if _, ok := weights[node]; !ok {
+			delete(s.addrs, node)
+		}
+	}
+}
+
+func resolveAll(servers []string) (map[string]net.Addr, error) {
+	addrs := make(map[string]net.Addr, len(servers))
+	for _, server := range servers {
+		addr, err := resolve(server)
+		if err != nil {
+			return nil, err
+		}
+		addrs[server] = addr
+	}
+	return addrs, nil
+}
+
+// resolve resolves server the same way memcache.ServerList does.
+func resolve(server string) (net.Addr, error) {
+	if strings.Contains(server, "/") {
+		return net.ResolveUnixAddr("unix", server)
+	}
+	return net.ResolveTCPAddr("tcp", server)
+}
@@ -0,0 +1,205 @@
+package memcacheselector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/serialx/hashring"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMemcached serves the get and set commands of the memcached text
+// protocol from memory.
+type fakeMemcached struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	items map[string][]byte
+}
+
+func startFakeMemcached(t *testing.T) *fakeMemcached {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeMemcached{listener: listener, items: make(map[string][]byte)}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *fakeMemcached) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeMemcached) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.items))
+	for key := range s.items {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *fakeMemcached) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeMemcached) handle(conn net.Conn) {
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return
+		}
+		switch fields[0] {
+		case "set":
+			size, _ := strconv.Atoi(fields[4])
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(rw, data); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.items[fields[1]] = data[:size]
+			s.mu.Unlock()
+			rw.WriteString("STORED\r\n")
+		case "get", "gets":
+			for _, key := range fields[1:] {
+				s.mu.Lock()
+				data, ok := s.items[key]
+				s.mu.Unlock()
+				if ok {
+					fmt.Fprintf(rw, "VALUE %s 0 %d 1\r\n%s\r\n", key, len(data), data)
+				}
+			}
+			rw.WriteString("END\r\n")
+		default:
+			rw.WriteString("ERROR\r\n")
+		}
+		if err := rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func TestSelector(t *testing.T) {
+	servers := []*fakeMemcached{startFakeMemcached(t), startFakeMemcached(t), startFakeMemcached(t)}
+	addrs := []string{servers[0].Addr(), servers[1].Addr()}
+
+	selector, err := New(addrs, hashring.WithReplicas(40))
+	assert.NoError(t, err)
+	client := memcache.NewFromSelector(selector)
+
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("key%d", i)
+		assert.NoError(t, client.Set(&memcache.Item{Key: key, Value: []byte(key)}))
+	}
+
+	for _, server := range servers[:2] {
+		assert.NotEmpty(t, server.Keys())
+		for _, key := range server.Keys() {
+			node, _ := selector.Ring().GetNode(key)
+			assert.Equal(t, server.Addr(), node, key)
+		}
+	}
+
+	item, err := client.Get("key7")
+	assert.NoError(t, err)
+	assert.Equal(t, "key7", string(item.Value))
+
+	seen := make([]string, 0)
+	assert.NoError(t, selector.Each(func(addr net.Addr) error {
+		seen = append(seen, addr.String())
+		return nil
+	}))
+	assert.ElementsMatch(t, addrs, seen)
+
+	// live update: keys which move to the new server are missed there
+	assert.NoError(t, selector.SetServers(append(addrs, servers[2].Addr())...))
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("key%d", i)
+		node, _ := selector.Ring().GetNode(key)
+		_, err := client.Get(key)
+		if node == servers[2].Addr() {
+			assert.ErrorIs(t, err, memcache.ErrCacheMiss, key)
+		} else {
+			assert.NoError(t, err, key)
+		}
+	}
+}
+
+func TestSelectorRingUpdates(t *testing.T) {
+	server := startFakeMemcached(t)
+	ring := hashring.NewAtomicRing(hashring.New(nil))
+	selector := NewFromRing(ring)
+
+	_, err := selector.PickServer("key")
+	assert.ErrorIs(t, err, memcache.ErrNoServers)
+
+	ring.Update(func(h *hashring.HashRing) *hashring.HashRing {
+		return h.AddNode(server.Addr())
+	})
+	addr, err := selector.PickServer("key")
+	assert.NoError(t, err)
+	assert.Equal(t, server.Addr(), addr.String())
+
+	assert.Error(t, selector.SetServers("not an address"))
+	assert.Equal(t, 1, ring.Load().Size())
+}
+
+type lookupCounter struct {
+	lookups atomic.Int64
+}
+
+func (c *lookupCounter) OnLookup(key, node string, pos int)            { c.lookups.Add(1) }
+func (c *lookupCounter) OnRebuild(oldRing, newRing *hashring.HashRing) {}
+func (c *lookupCounter) OnNodeSkipped(key, node string)                {}
+
+func TestSelectorEach(t *testing.T) {
+	servers := []*fakeMemcached{startFakeMemcached(t), startFakeMemcached(t)}
+	counter := &lookupCounter{}
+	selector, err := New([]string{servers[0].Addr(), servers[1].Addr()}, hashring.WithObserver(counter))
+	assert.NoError(t, err)
+
+	var addrs []string
+	assert.NoError(t, selector.Each(func(addr net.Addr) error {
+		addrs = append(addrs, addr.String())
+		return nil
+	}))
+	assert.ElementsMatch(t, []string{servers[0].Addr(), servers[1].Addr()}, addrs)
+	assert.Zero(t, counter.lookups.Load())
+
+	// servers removed through the shared ring are dropped from the cache
+	selector.Ring().Update(func(h *hashring.HashRing) *hashring.HashRing {
+		return h.RemoveNode(servers[0].Addr())
+	})
+	addrs = nil
+	assert.NoError(t, selector.Each(func(addr net.Addr) error {
+		addrs = append(addrs, addr.String())
+		return nil
+	}))
+	assert.Equal(t, []string{servers[1].Addr()}, addrs)
+	selector.mu.RLock()
+	assert.NotContains(t, selector.addrs, servers[0].Addr())
+	selector.mu.RUnlock()
+}
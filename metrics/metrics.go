@@ -0,0 +1,269 @@
+// Package metrics exposes the state of a consistent hashing ring in the
+// Prometheus text exposition format without depending on a Prometheus
+// client library:
+//
+//	ring := metrics.New(hashring.NewAtomicRing(hashring.New(nodes)))
+//	http.Handle("/metrics", ring)
+//	node, ok := ring.GetNode(key)
+//
+// Lookups have to go through the Collector to be counted. Rings stored
+// elsewhere, e.g. by a hashring.FileSource or a hashring.RingManager
+// sharing the AtomicRing, are counted as rebuilds when the Collector next
+// sees the ring on a lookup or a scrape, so several of them in between
+// count as one, and their change time is when it saw them. Only updates
+// through Collector.Update are timed.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/serialx/hashring"
+)
+
+// Collector wraps a hashring.AtomicRing and records metrics of its
+// lookups and rebuilds.
+type Collector struct {
+	ring *hashring.AtomicRing
+
+	last     atomic.Pointer[hashring.HashRing]
+	rebuilds atomic.Uint64
+	// lastChange is in Unix nanoseconds, 0 until the ring changes
+	lastChange atomic.Int64
+	// lookups maps nodes to their *atomic.Uint64 lookup counts
+	lookups sync.Map
+
+	mu              sync.Mutex
+	timedRebuilds   uint64
+	rebuildDuration time.Duration
+}
+
+func New(ring *hashring.AtomicRing) *Collector {
+	c := &Collector{ring: ring}
+	c.last.Store(ring.Load())
+	return c
+}
+
+func (c *Collector) Ring() *hashring.AtomicRing {
+	return c.ring
+}
+
+func (c *Collector) GetNode(stringKey string) (node string, ok bool) {
+	ring := c.ring.Load()
+	node, ok = ring.GetNode(stringKey)
+	c.observe(ring)
+	if ok {
+		c.countLookup(node)
+	}
+	return node, ok
+}
+
+// GetNodes counts a lookup of the first node only, which is the owner
+// of the key.
+func (c *Collector) GetNodes(stringKey string, size int) (nodes []string, ok bool) {
+	ring := c.ring.Load()
+	nodes, ok = ring.GetNodes(stringKey, size)
+	c.observe(ring)
+	if len(nodes) > 0 {
+		c.countLookup(nodes[0])
+	}
+	return nodes, ok
+}
+
+func (c *Collector) countLookup(node string) {
+	count, ok := c.lookups.Load(node)
+	if !ok {
+		count, _ = c.lookups.LoadOrStore(node, new(atomic.Uint64))
+	}
+	count.(*atomic.Uint64).Add(1)
+}
+
+// observe counts a rebuild if ring, used by a lookup or a scrape, isn't
+// the ring seen last. A ring replaced since it was loaded is ignored, so
+// lookups racing with a swap don't count it twice.
+func (c *Collector) observe(ring *hashring.HashRing) {
+	if ring == c.ring.Load() {
+		c.see(ring)
+	}
+}
+
+// see counts a rebuild if ring isn't the ring seen last.
+func (c *Collector) see(ring *hashring.HashRing) {
+	last := c.last.Load()
+	if ring != last && c.last.CompareAndSwap(last, ring) {
+		c.rebuilds.Add(1)
+		c.lastChange.Store(time.Now().UnixNano())
+	}
+}
+
+// Update works like hashring.AtomicRing.Update and records the time it
+// took if the ring changed.
+func (c *Collector) Update(update func(ring *hashring.HashRing) *hashring.HashRing) (oldRing, newRing *hashring.HashRing) {
+	start := time.Now()
+	oldRing, newRing = c.ring.Update(update)
+	if oldRing != newRing {
+		c.recordRebuild(oldRing, newRing, time.Since(start))
+	}
+	return oldRing, newRing
+}
+
+func (c *Collector) recordRebuild(oldRing, newRing *hashring.HashRing, duration time.Duration) {
+	// count rings stored by others before this update
+	c.see(oldRing)
+	c.see(newRing)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timedRebuilds++
+	c.rebuildDuration += duration
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	ring := c.ring.Load()
+	points := 0
+	for range ring.All() {
+		points++
+	}
+
+	c.observe(ring)
+	rebuilds := c.rebuilds.Load()
+	lastChange := c.lastChange.Load()
+	lookups := make(map[string]uint64)
+	c.lookups.Range(func(node, count any) bool {
+		lookups[node.(string)] = count.(*atomic.Uint64).Load()
+		return true
+	})
+	c.mu.Lock()
+	timedRebuilds := c.timedRebuilds
+	rebuildDuration := c.rebuildDuration
+	c.mu.Unlock()
+
+	e := newEncoder(w)
+	e.family("hashring_nodes", "gauge", "Number of nodes in the ring.")
+	e.sample("hashring_nodes", nil, float64(ring.Size()))
+	e.family("hashring_virtual_points", "gauge", "Number of virtual points on the ring.")
+	e.sample("hashring_virtual_points", nil, float64(points))
+
+	e.family("hashring_node_ownership_ratio", "gauge", "Share of the hash space owned by a node.")
+	ownership := ring.Ownership()
+	for _, node := range sortedKeys(ownership) {
+		e.sample("hashring_node_ownership_ratio", []string{"node", node}, ownership[node])
+	}
+
+	e.family("hashring_lookups_total", "counter", "Number of keys looked up, by owning node.")
+	for _, node := range sortedKeys(lookups) {
+		e.sample("hashring_lookups_total", []string{"node", node}, float64(lookups[node]))
+	}
+
+	e.family("hashring_rebuilds_total", "counter", "Number of ring rebuilds.")
+	e.sample("hashring_rebuilds_total", nil, float64(rebuilds))
+	e.family("hashring_rebuild_duration_seconds", "summary", "Time spent rebuilding the ring in Collector.Update.")
+	e.sample("hashring_rebuild_duration_seconds_sum", nil, rebuildDuration.Seconds())
+	e.sample("hashring_rebuild_duration_seconds_count", nil, float64(timedRebuilds))
+	if lastChange != 0 {
+		e.family("hashring_last_change_timestamp_seconds", "gauge",
+			"Unix time the collector saw the last ring change, on the first lookup or scrape after changes made outside Collector.Update.")
+		e.sample("hashring_last_change_timestamp_seconds", nil, float64(lastChange)/1e9)
+	}
+	return e.flush()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// encoder writes the Prometheus text exposition format.
+type encoder struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func newEncoder(w io.Writer) *encoder {
+	return &encoder{w: bufio.NewWriter(w)}
+}
+
+func (e *encoder) family(name, kind, help string) {
+	e.printf("# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+}
+
+// sample writes a sample; labels alternate between names and values.
+func (e *encoder) sample(name string, labels []string, value float64) {
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(labels) > 0 {
+		sb.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(labels[i])
+			sb.WriteString(`="`)
+			sb.WriteString(escapeLabel(labels[i+1]))
+			sb.WriteByte('"')
+		}
+		sb.WriteByte('}')
+	}
+	e.printf("%s %s\n", sb.String(), formatValue(value))
+}
+
+func (e *encoder) printf(format string, args ...any) {
+	if e.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(e.w, format, args...)
+	e.n += int64(n)
+	e.err = err
+}
+
+func (e *encoder) flush() (int64, error) {
+	if e.err != nil {
+		return e.n, e.err
+	}
+	return e.n, e.w.Flush()
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/serialx/hashring"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	nodes := []string{"a", "b", `c"\`}
+	collector := New(hashring.NewAtomicRing(hashring.New(nodes, hashring.WithReplicas(10))))
+
+	lookups := make(map[string]int)
+	for _, key := range []string{"test", "test1", "test2", "test3"} {
+		node, ok := collector.GetNode(key)
+		assert.True(t, ok)
+		lookups[node]++
+	}
+	replicas, _ := collector.GetNodes("test", 2)
+	lookups[replicas[0]]++
+
+	collector.Update(func(ring *hashring.HashRing) *hashring.HashRing { return ring.AddNode("d") })
+	collector.Update(func(ring *hashring.HashRing) *hashring.HashRing { return ring.AddNode("d") })
+
+	recorder := httptest.NewRecorder()
+	collector.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+	body := recorder.Body.String()
+
+	for _, line := range []string{
+		"# HELP hashring_nodes Number of nodes in the ring.",
+		"# TYPE hashring_nodes gauge",
+		"hashring_nodes 4",
+		"hashring_virtual_points 40",
+		"# TYPE hashring_lookups_total counter",
+		"hashring_rebuilds_total 1",
+		"hashring_rebuild_duration_seconds_count 1",
+		"# TYPE hashring_rebuild_duration_seconds summary",
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+	assert.Contains(t, body, `hashring_node_ownership_ratio{node="c\"\\"} `)
+	assert.Contains(t, body, "hashring_last_change_timestamp_seconds ")
+
+	samples := 0
+	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+		if strings.HasPrefix(line, "hashring_lookups_total{") {
+			samples++
+		}
+		if strings.HasPrefix(line, "hashring_node_ownership_ratio{") {
+			samples++
+		}
+	}
+	assert.Equal(t, len(lookups)+4, samples)
+	for node, count := range lookups {
+		if node == "a" || node == "b" {
+			assert.Contains(t, body, `hashring_lookups_total{node="`+node+`"} `+strconv.Itoa(count)+"\n")
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	assert.Equal(t, "0.25", formatValue(0.25))
+	assert.Equal(t, "1e+06", formatValue(1e6))
+	assert.Equal(t, "12", formatValue(12))
+}
+
+func scrape(collector *Collector) string {
+	var sb strings.Builder
+	collector.WriteTo(&sb)
+	return sb.String()
+}
+
+func TestCollectorExternalUpdates(t *testing.T) {
+	ring := hashring.NewAtomicRing(hashring.New([]string{"a", "b"}))
+	collector := New(ring)
+
+	body := scrape(collector)
+	assert.Contains(t, body, "hashring_rebuilds_total 0\n")
+	assert.NotContains(t, body, "hashring_last_change_timestamp_seconds")
+
+	// rings stored by sources sharing the AtomicRing are counted
+	ring.UpdateWithFloatWeights(map[string]float64{"a": 1, "b": 1, "c": 1})
+	body = scrape(collector)
+	assert.Contains(t, body, "hashring_rebuilds_total 1\n")
+	assert.Contains(t, body, "hashring_rebuild_duration_seconds_count 0\n")
+	assert.Contains(t, body, "hashring_last_change_timestamp_seconds ")
+	assert.Contains(t, scrape(collector), "hashring_rebuilds_total 1\n")
+
+	ring.UpdateWithFloatWeights(map[string]float64{"a": 1})
+	collector.GetNode("key")
+	collector.Update(func(h *hashring.HashRing) *hashring.HashRing { return h.AddNode("d") })
+	body = scrape(collector)
+	assert.Contains(t, body, "hashring_rebuilds_total 3\n")
+	assert.Contains(t, body, "hashring_rebuild_duration_seconds_count 1\n")
+
+	ring.Store(hashring.New([]string{"e"}))
+	collector.Update(func(h *hashring.HashRing) *hashring.HashRing { return h.AddNode("f") })
+	assert.Contains(t, scrape(collector), "hashring_rebuilds_total 5\n")
+}
+
+func TestCollectorConcurrentLookups(t *testing.T) {
+	collector := New(hashring.NewAtomicRing(hashring.New([]string{"a", "b", "c"})))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				collector.GetNode("key" + strconv.Itoa(j))
+			}
+		}()
+	}
+	collector.Update(func(h *hashring.HashRing) *hashring.HashRing { return h.AddNode("d") })
+	wg.Wait()
+
+	total := 0.0
+	for _, line := range strings.Split(scrape(collector), "\n") {
+		if strings.HasPrefix(line, "hashring_lookups_total{") {
+			count, err := strconv.ParseFloat(line[strings.LastIndexByte(line, ' ')+1:], 64)
+			assert.NoError(t, err)
+			total += count
+		}
+	}
+	assert.Equal(t, 8000.0, total)
+	assert.Contains(t, scrape(collector), "hashring_rebuilds_total 1\n")
+}
@@ -0,0 +1,19 @@
+package hashring
+
+// Observer is notified about the lookups and rebuilds of a ring, e.g. to
+// record metrics or traces. Its methods are called synchronously, so they
+// should be fast and must be safe for concurrent use. A ring without an
+// observer doesn't pay for the notifications.
+type Observer interface {
+	// OnLookup is called once per key resolved by GetNode, GetNodes,
+	// GetNodesFunc or GetNodesBatch with the node owning the key and the
+	// position of its point on the ring, or -1 if the key was routed by
+	// overrides or hot keys.
+	OnLookup(key, node string, pos int)
+	// OnRebuild is called when a ring derived from oldRing was built by
+	// AddNode, RemoveNode, UpdateWeightedNode or UpdateWithWeights.
+	OnRebuild(oldRing, newRing *HashRing)
+	// OnNodeSkipped is called when GetNodesFunc skips a node rejected by
+	// its predicate.
+	OnNodeSkipped(key, node string)
+}
@@ -0,0 +1,97 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type lookup struct {
+	key, node string
+	pos       int
+}
+
+type recorder struct {
+	lookups  []lookup
+	rebuilds [][2]*HashRing
+	skipped  []string
+}
+
+func (r *recorder) OnLookup(key, node string, pos int) {
+	r.lookups = append(r.lookups, lookup{key, node, pos})
+}
+
+func (r *recorder) OnRebuild(oldRing, newRing *HashRing) {
+	r.rebuilds = append(r.rebuilds, [2]*HashRing{oldRing, newRing})
+}
+
+func (r *recorder) OnNodeSkipped(key, node string) {
+	r.skipped = append(r.skipped, key+"/"+node)
+}
+
+func TestObserverLookup(t *testing.T) {
+	r := &recorder{}
+	ring := New([]string{"a", "b", "c"}, WithObserver(r))
+
+	node, _ := ring.GetNode("test")
+	pos, _ := ring.GetNodePos("test")
+	assert.Equal(t, []lookup{{"test", node, pos}}, r.lookups)
+
+	r.lookups = nil
+	nodes, _ := ring.GetNodes("test", 2)
+	assert.Equal(t, []lookup{{"test", nodes[0], pos}}, r.lookups)
+
+	r.lookups = nil
+	nodes, _ = ring.GetNodesBatch([]string{"test", "test2"})
+	pos2, _ := ring.GetNodePos("test2")
+	assert.Equal(t, []lookup{{"test", nodes[0], pos}, {"test2", nodes[1], pos2}}, r.lookups)
+}
+
+func TestObserverRoutedLookup(t *testing.T) {
+	r := &recorder{}
+	overrides := NewOverrides()
+	overrides.Pin("pinned", "c")
+	ring := New([]string{"a", "b", "c"},
+		WithObserver(r),
+		WithOverrides(overrides),
+		WithHotKeys(2, RoundRobinSelector(), "hot"))
+
+	ring.GetNode("pinned")
+	node, _ := ring.GetNode("hot")
+	assert.Equal(t, []lookup{{"pinned", "c", -1}, {"hot", node, -1}}, r.lookups)
+}
+
+func TestObserverNodeSkipped(t *testing.T) {
+	r := &recorder{}
+	ring := New([]string{"a", "b", "c"}, WithObserver(r))
+	owner, _ := ring.GetNode("test")
+
+	r.lookups = nil
+	nodes, ok := ring.GetNodesFunc("test", 1, func(node string) bool {
+		return node != owner
+	})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"test/" + owner}, r.skipped)
+	assert.Len(t, r.lookups, 1)
+	assert.Equal(t, nodes[0], r.lookups[0].node)
+	assert.Equal(t, nodes[0], ring.ring[ring.sortedKeys[r.lookups[0].pos]])
+}
+
+func TestObserverRebuild(t *testing.T) {
+	r := &recorder{}
+	ring := New([]string{"a", "b"}, WithObserver(r))
+	assert.Empty(t, r.rebuilds)
+
+	added := ring.AddNode("c")
+	assert.Equal(t, [][2]*HashRing{{ring, added}}, r.rebuilds)
+
+	r.rebuilds = nil
+	added.UpdateWithWeights(map[string]int{"a": 1, "b": 1, "c": 1})
+	assert.Empty(t, r.rebuilds)
+
+	added.UpdateWithWeights(map[string]int{"a": 2})
+	assert.Len(t, r.rebuilds, 1)
+	assert.Equal(t, 3, r.rebuilds[0][0].Size())
+	assert.Equal(t, 1, r.rebuilds[0][1].Size())
+	assert.Equal(t, 1, added.Size())
+}
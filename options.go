@@ -0,0 +1,92 @@
+package hashring
+
+import "maps"
+
+// Option configures optional behaviour of a HashRing. Options are passed to
+// the New* functions and are kept by every ring derived from the configured
+// one with AddNode, RemoveNode, UpdateWeightedNode and UpdateWithWeights.
+type Option func(*HashRing)
+
+// WithVNodeKeyFunc sets the naming scheme of virtual points, e.g. to match
+// the names used by another implementation. Only the names change: every
+// name still places one point, so implementations placing several points
+// per hashed name, like libketama, are not reproduced.
+//
+//	hashring.New(nodes, hashring.WithVNodeKeyFunc(func(node string, i int) string {
+//		return fmt.Sprintf("%s#%d", node, i)
+//	}))
+func WithVNodeKeyFunc(vnodeKey VNodeKeyFunc) Option {
+	return func(h *HashRing) {
+		h.vnodeKey = vnodeKey
+	}
+}
+
+// WithReplicas sets the number of virtual points placed on the ring per
+// unit of weight. With the default of 1 a node gets as many points as its
+// weight, which leaves rings with few nodes badly balanced; libketama
+// uses 160.
+func WithReplicas(replicas int) Option {
+	return func(h *HashRing) {
+		if replicas > 0 {
+			h.replicas = replicas
+		}
+	}
+}
+
+// WithOverrides makes GetNode and GetNodes route the keys pinned by
+// overrides to their nodes instead of consulting the ring.
+func WithOverrides(overrides *Overrides) Option {
+	return func(h *HashRing) {
+		h.overrides = overrides
+	}
+}
+
+// WithHotKeys makes GetNode spread the requests for each of the given keys
+// over the first fanout nodes returned by GetNodes, using selector to pick
+// one of them per request, or RandomSelector if selector is nil. The
+// placement of the ring is not affected.
+func WithHotKeys(fanout int, selector ReplicaSelector, keys ...string) Option {
+	return func(h *HashRing) {
+		if fanout < 1 {
+			return
+		}
+		if selector == nil {
+			selector = RandomSelector()
+		}
+		hot := &hotKeys{
+			fanout:   fanout,
+			selector: selector,
+			keys:     make(map[string]bool, len(keys)),
+		}
+		for _, key := range keys {
+			hot.keys[key] = true
+		}
+		h.hotKeys = hot
+	}
+}
+
+// WithLabels sets the labels of the nodes of a new ring.
+func WithLabels(labels map[string]Labels) Option {
+	return func(h *HashRing) {
+		for node, nodeLabels := range labels {
+			h.labels[node] = maps.Clone(nodeLabels)
+		}
+	}
+}
+
+// WithKeyExtractor makes the ring hash only the part of every key returned
+// by extract, so related keys are stored on the same nodes. Overrides and
+// hot keys still match the whole key.
+func WithKeyExtractor(extract KeyExtractor) Option {
+	return func(h *HashRing) {
+		h.extractKey = extract
+	}
+}
+
+// WithObserver notifies observer about the lookups and rebuilds of the
+// ring and of all rings derived from it.
+func WithObserver(observer Observer) Option {
+	return func(h *HashRing) {
+		h.observer = observer
+	}
+}
@@ -0,0 +1,81 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVNodeKeyFuncDefault(t *testing.T) {
+	weights := map[string]int{"a": 1, "b": 2, "c": 1}
+	ring := NewWithWeights(weights, WithVNodeKeyFunc(DefaultVNodeKey))
+
+	assert.Equal(t, NewWithWeights(weights).sortedKeys, ring.sortedKeys)
+}
+
+func TestVNodeKeyFunc(t *testing.T) {
+	var named []string
+	vnodeKey := func(node string, replica int) string {
+		key := node + "#" + strconv.Itoa(replica)
+		named = append(named, key)
+		return key
+	}
+
+	ring := NewWithWeights(map[string]int{"a": 2}, WithVNodeKeyFunc(vnodeKey))
+	assert.ElementsMatch(t, []string{"a#0", "a#1"}, named)
+	assert.ElementsMatch(t, []HashKey{
+		defaultHashFunc([]byte("a#0")),
+		defaultHashFunc([]byte("a#1")),
+	}, ring.sortedKeys)
+
+	named = nil
+	ring = ring.AddNode("b")
+	assert.ElementsMatch(t, []string{"a#0", "a#1", "b#0"}, named)
+
+	named = nil
+	ring.UpdateWithWeights(map[string]int{"c": 1})
+	assert.Equal(t, []string{"c#0"}, named)
+}
+
+func TestReplicas(t *testing.T) {
+	ring := New([]string{"a", "b", "c"}, WithReplicas(100))
+	assert.Len(t, ring.sortedKeys, 300)
+
+	ring = ring.AddFloatWeightedNode("d", 1.5)
+	assert.Len(t, ring.sortedKeys, 450)
+
+	ring = ring.UpdateFloatWeightedNode("d", 0.25)
+	assert.Len(t, ring.sortedKeys, 325)
+
+	ring = ring.RemoveNode("a")
+	assert.Len(t, ring.sortedKeys, 225)
+}
+
+func TestReplicasBalance(t *testing.T) {
+	nodes := []string{"a", "b", "c", "d"}
+	ring := New(nodes, WithReplicas(160))
+
+	counts := make(map[string]int)
+	const keys = 40000
+	for i := 0; i < keys; i++ {
+		node, ok := ring.GetNode("key" + strconv.Itoa(i))
+		assert.True(t, ok)
+		counts[node]++
+	}
+	for _, node := range nodes {
+		assert.InDelta(t, keys/len(nodes), counts[node], keys*0.05, node)
+	}
+}
+
+func TestFloatWeights(t *testing.T) {
+	ring := NewWithFloatWeights(map[string]float64{"a": 1.5, "b": 0.5}, WithReplicas(10))
+	assert.Len(t, ring.sortedKeys, 20)
+
+	// Every node keeps at least one point on the ring.
+	ring = NewWithFloatWeights(map[string]float64{"a": 0.1, "b": 1})
+	assert.Len(t, ring.sortedKeys, 2)
+	nodes, ok := ring.GetNodes("test", 2)
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []string{"a", "b"}, nodes)
+}
@@ -0,0 +1,108 @@
+package hashring
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// Overrides pins keys to nodes, bypassing the ring. Exact keys take
+// precedence over prefixes and the longest matching prefix wins. A pin
+// whose node is not a member of the ring is ignored, so the key falls
+// back to consistent hashing until the node is added again.
+//
+// Overrides is safe for concurrent use. It is shared by all rings derived
+// from the ring it was configured on, so pins survive ring updates.
+type Overrides struct {
+	mu       sync.RWMutex
+	keys     map[string]string
+	prefixes map[string]string
+}
+
+func NewOverrides() *Overrides {
+	return &Overrides{
+		keys:     make(map[string]string),
+		prefixes: make(map[string]string),
+	}
+}
+
+// Pin routes key to node.
+func (o *Overrides) Pin(key, node string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.keys == nil {
+		o.keys = make(map[string]string)
+	}
+	o.keys[key] = node
+}
+
+// PinPrefix routes every key starting with prefix to node.
+func (o *Overrides) PinPrefix(prefix, node string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.prefixes == nil {
+		o.prefixes = make(map[string]string)
+	}
+	o.prefixes[prefix] = node
+}
+
+func (o *Overrides) Unpin(key string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.keys, key)
+}
+
+func (o *Overrides) UnpinPrefix(prefix string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.prefixes, prefix)
+}
+
+// Lookup returns the node key is pinned to.
+func (o *Overrides) Lookup(key string) (node string, ok bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if node, ok := o.keys[key]; ok {
+		return node, true
+	}
+
+	longest := -1
+	for prefix, prefixNode := range o.prefixes {
+		if len(prefix) > longest && strings.HasPrefix(key, prefix) {
+			longest = len(prefix)
+			node = prefixNode
+		}
+	}
+	return node, longest >= 0
+}
+
+type overridesJSON struct {
+	Keys     map[string]string `json:"keys,omitempty"`
+	Prefixes map[string]string `json:"prefixes,omitempty"`
+}
+
+func (o *Overrides) MarshalJSON() ([]byte, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return json.Marshal(overridesJSON{Keys: o.keys, Prefixes: o.prefixes})
+}
+
+func (o *Overrides) UnmarshalJSON(data []byte) error {
+	var decoded overridesJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Keys == nil {
+		decoded.Keys = make(map[string]string)
+	}
+	if decoded.Prefixes == nil {
+		decoded.Prefixes = make(map[string]string)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.keys = decoded.Keys
+	o.prefixes = decoded.Prefixes
+	return nil
+}
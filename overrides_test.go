@@ -0,0 +1,83 @@
+package hashring
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverridesLookup(t *testing.T) {
+	overrides := NewOverrides()
+	overrides.Pin("tenant:1:hot", "c")
+	overrides.PinPrefix("tenant:", "a")
+	overrides.PinPrefix("tenant:1:", "b")
+
+	for key, expected := range map[string]string{
+		"tenant:1:hot":  "c",
+		"tenant:1:cold": "b",
+		"tenant:2:hot":  "a",
+	} {
+		node, ok := overrides.Lookup(key)
+		assert.True(t, ok, key)
+		assert.Equal(t, expected, node, key)
+	}
+
+	_, ok := overrides.Lookup("user:1")
+	assert.False(t, ok)
+
+	overrides.Unpin("tenant:1:hot")
+	overrides.UnpinPrefix("tenant:1:")
+	node, _ := overrides.Lookup("tenant:1:hot")
+	assert.Equal(t, "a", node)
+}
+
+func TestOverridesGetNode(t *testing.T) {
+	overrides := NewOverrides()
+	ring := New([]string{"a", "b", "c"}, WithOverrides(overrides))
+
+	// "test" hashes to "a", see expectNodesABC
+	overrides.Pin("test", "b")
+	node, ok := ring.GetNode("test")
+	assert.True(t, ok)
+	assert.Equal(t, "b", node)
+
+	nodes, ok := ring.GetNodes("test", 2)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"b", "a"}, nodes)
+
+	nodes, ok = ring.GetNodes("test", 3)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"b", "a", "c"}, nodes)
+
+	// pins survive ring updates
+	ring = ring.AddNode("d")
+	node, _ = ring.GetNode("test")
+	assert.Equal(t, "b", node)
+
+	// and fall back to the ring while the pinned node is gone
+	ring = ring.RemoveNode("b")
+	node, _ = ring.GetNode("test")
+	assert.Equal(t, "d", node)
+
+	ring = ring.AddNode("b")
+	node, _ = ring.GetNode("test")
+	assert.Equal(t, "b", node)
+}
+
+func TestOverridesJSON(t *testing.T) {
+	overrides := NewOverrides()
+	overrides.Pin("hot", "a")
+	overrides.PinPrefix("tenant:", "b")
+
+	data, err := json.Marshal(overrides)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"keys":{"hot":"a"},"prefixes":{"tenant:":"b"}}`, string(data))
+
+	decoded := &Overrides{}
+	assert.NoError(t, json.Unmarshal(data, decoded))
+	node, _ := decoded.Lookup("tenant:42")
+	assert.Equal(t, "b", node)
+	node, _ = decoded.Lookup("hot")
+	assert.Equal(t, "a", node)
+}
@@ -0,0 +1,65 @@
+package hashring
+
+import "math"
+
+// FractionalHashKey is implemented by hash keys which know their position
+// on the ring as a fraction of the hash space in [0, 1). The keys of the
+// default hash function and Uint32HashKey implement it.
+type FractionalHashKey interface {
+	HashKey
+	Fraction() float64
+}
+
+func (k *Int64PairHashKey) Fraction() float64 {
+	// Less orders High as a signed integer
+	return (float64(k.High) + math.Exp2(63)) / math.Exp2(64)
+}
+
+func (k Uint32HashKey) Fraction() float64 {
+	return float64(k) / math.Exp2(32)
+}
+
+// Arc is the part of the hash space owned by a virtual point: the keys
+// between the previous point and the point itself. Start and End are
+// fractions of the hash space. The arc of the first point wraps around
+// zero, so its Start is negative.
+type Arc struct {
+	Point
+	Start, End float64
+}
+
+// Arcs returns the arcs of the virtual points in clockwise order. If the
+// hash keys don't implement FractionalHashKey, the points are spaced
+// evenly.
+func (h *HashRing) Arcs() []Arc {
+	arcs := make([]Arc, len(h.sortedKeys))
+	if len(arcs) == 0 {
+		return arcs
+	}
+	_, fractional := h.sortedKeys[0].(FractionalHashKey)
+	for pos, key := range h.sortedKeys {
+		arcs[pos].Point = Point{Pos: pos, Token: key, Node: h.ring[key]}
+		if fractional {
+			arcs[pos].End = key.(FractionalHashKey).Fraction()
+		} else {
+			arcs[pos].End = float64(pos+1) / float64(len(arcs))
+		}
+	}
+
+	arcs[0].Start = arcs[len(arcs)-1].End - 1
+	for pos := 1; pos < len(arcs); pos++ {
+		arcs[pos].Start = arcs[pos-1].End
+	}
+	return arcs
+}
+
+// Ownership returns the share of the hash space owned by every node, which
+// is the expected share of keys it stores. If the hash keys don't
+// implement FractionalHashKey, the share of virtual points is returned.
+func (h *HashRing) Ownership() map[string]float64 {
+	ownership := make(map[string]float64, len(h.nodes))
+	for _, arc := range h.Arcs() {
+		ownership[arc.Node] += arc.End - arc.Start
+	}
+	return ownership
+}
@@ -0,0 +1,91 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnership(t *testing.T) {
+	ring := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1}, WithReplicas(100))
+
+	ownership := ring.Ownership()
+	total := 0.0
+	for _, share := range ownership {
+		total += share
+	}
+	assert.InDelta(t, 1, total, 1e-9)
+
+	counts := make(map[string]int)
+	const keys = 100000
+	for i := 0; i < keys; i++ {
+		node, _ := ring.GetNode(fmt.Sprintf("key%d", i))
+		counts[node]++
+	}
+	for node, share := range ownership {
+		assert.InDelta(t, share, float64(counts[node])/keys, 0.01, node)
+	}
+	assert.InDelta(t, 0.5, ownership["b"], 0.05)
+
+	assert.Equal(t, map[string]float64{"a": 1}, New([]string{"a"}).Ownership())
+	assert.Empty(t, New(nil).Ownership())
+}
+
+func TestOwnershipUint32(t *testing.T) {
+	hashFunc := func(key []byte) HashKey {
+		switch string(key) {
+		case "a-0":
+			return Uint32HashKey(1 << 30)
+		case "b-0":
+			return Uint32HashKey(1 << 31)
+		}
+		return Uint32HashKey(0)
+	}
+	ring := NewWithHash([]string{"a", "b"}, hashFunc)
+	assert.Equal(t, map[string]float64{"a": 0.75, "b": 0.25}, ring.Ownership())
+}
+
+func TestArcs(t *testing.T) {
+	hashFunc := func(key []byte) HashKey {
+		switch string(key) {
+		case "a-0":
+			return Uint32HashKey(1 << 30)
+		case "b-0":
+			return Uint32HashKey(1 << 31)
+		}
+		return Uint32HashKey(0)
+	}
+	ring := NewWithHash([]string{"a", "b"}, hashFunc)
+	assert.Equal(t, []Arc{
+		{Point: Point{Pos: 0, Token: Uint32HashKey(1 << 30), Node: "a"}, Start: -0.5, End: 0.25},
+		{Point: Point{Pos: 1, Token: Uint32HashKey(1 << 31), Node: "b"}, Start: 0.25, End: 0.5},
+	}, ring.Arcs())
+	assert.Empty(t, New(nil).Arcs())
+}
+
+// stringHashKey is a HashKey without a position in the hash space.
+type stringHashKey string
+
+func (k stringHashKey) Less(other HashKey) bool {
+	return k < other.(stringHashKey)
+}
+
+func TestArcsNonFractional(t *testing.T) {
+	hashFunc := func(key []byte) HashKey {
+		return stringHashKey(key)
+	}
+	ring := NewWithHash([]string{"a", "b", "c"}, hashFunc)
+
+	assert.Equal(t, []Arc{
+		{Point: Point{Pos: 0, Token: stringHashKey("a-0"), Node: "a"}, Start: 0, End: 1.0 / 3},
+		{Point: Point{Pos: 1, Token: stringHashKey("b-0"), Node: "b"}, Start: 1.0 / 3, End: 2.0 / 3},
+		{Point: Point{Pos: 2, Token: stringHashKey("c-0"), Node: "c"}, Start: 2.0 / 3, End: 1},
+	}, ring.Arcs())
+
+	ownership := ring.Ownership()
+	assert.Len(t, ownership, 3)
+	for _, node := range []string{"a", "b", "c"} {
+		assert.InDelta(t, 1.0/3, ownership[node], 1e-9, node)
+	}
+}
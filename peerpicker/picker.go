@@ -0,0 +1,105 @@
+// Package peerpicker implements the PeerPicker contract of groupcache-like
+// libraries on top of a consistent hashing ring. With P set to
+// groupcache.ProtoGetter, a *Picker satisfies groupcache.PeerPicker:
+//
+//	picker := peerpicker.New(self, func(peer string) groupcache.ProtoGetter {
+//		return newHTTPGetter(peer)
+//	}, hashring.WithReplicas(160))
+//	picker.Set(peers...)
+//	groupcache.RegisterPeerPicker(func() groupcache.PeerPicker { return picker })
+package peerpicker
+
+import (
+	"sync"
+
+	"github.com/serialx/hashring"
+)
+
+// Picker picks the peer which owns a key. Nodes of the ring are peer names,
+// e.g. base URLs, one of which is the local node.
+type Picker[P any] struct {
+	self    string
+	newPeer func(node string) P
+	ring    *hashring.AtomicRing
+
+	mu    sync.RWMutex
+	peers map[string]P
+}
+
+// New creates a picker for the local node self. newPeer creates the
+// handle used to talk to a remote peer; it is called once per peer.
+func New[P any](self string, newPeer func(node string) P, opts ...hashring.Option) *Picker[P] {
+	return &Picker[P]{
+		self:    self,
+		newPeer: newPeer,
+		ring:    hashring.NewAtomicRing(hashring.New(nil, opts...)),
+		peers:   make(map[string]P),
+	}
+}
+
+func (p *Picker[P]) Ring() *hashring.AtomicRing {
+	return p.ring
+}
+
+// Set replaces the peers, each with weight 1. The list should include the
+// local node.
+func (p *Picker[P]) Set(peers ...string) {
+	weights := make(map[string]float64, len(peers))
+	for _, peer := range peers {
+		weights[peer] = 1
+	}
+	p.SetWeighted(weights)
+}
+
+// SetWeighted replaces the peers and their weights.
+func (p *Picker[P]) SetWeighted(weights map[string]float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	peers := make(map[string]P, len(weights))
+	for node := range weights {
+		if node == p.self {
+			continue
+		}
+		if peer, ok := p.peers[node]; ok {
+			peers[node] = peer
+		} else {
+			peers[node] = p.newPeer(node)
+		}
+	}
+	p.peers = peers
+	p.ring.UpdateWithFloatWeights(weights)
+}
+
+// PickPeer returns the peer which owns key. It returns ok=false if there
+// are no peers or the key is owned by the local node.
+func (p *Picker[P]) PickPeer(key string) (peer P, ok bool) {
+	node, ok := p.ring.GetNode(key)
+	if !ok || node == p.self {
+		return peer, false
+	}
+	return p.peer(node)
+}
+
+// PickPeers returns the remote peers among the first n owners of key, in
+// ring order. It can be used to read a key from replicas.
+func (p *Picker[P]) PickPeers(key string, n int) []P {
+	nodes, _ := p.ring.Load().GetNodesFunc(key, n, nil)
+	peers := make([]P, 0, len(nodes))
+	for _, node := range nodes {
+		if node == p.self {
+			continue
+		}
+		if peer, ok := p.peer(node); ok {
+			peers = append(peers, peer)
+		}
+	}
+	return peers
+}
+
+func (p *Picker[P]) peer(node string) (peer P, ok bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	peer, ok = p.peers[node]
+	return peer, ok
+}
@@ -0,0 +1,65 @@
+package peerpicker
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/serialx/hashring"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPeer struct {
+	name string
+}
+
+func TestPicker(t *testing.T) {
+	created := make([]string, 0)
+	newPeer := func(node string) *testPeer {
+		created = append(created, node)
+		return &testPeer{name: node}
+	}
+	picker := New("http://a", newPeer, hashring.WithReplicas(40))
+
+	_, ok := picker.PickPeer("key")
+	assert.False(t, ok)
+
+	picker.Set("http://a", "http://b", "http://c")
+	assert.ElementsMatch(t, []string{"http://b", "http://c"}, created)
+
+	local := 0
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		owner, _ := picker.Ring().GetNode(key)
+		peer, ok := picker.PickPeer(key)
+		if owner == "http://a" {
+			assert.False(t, ok, key)
+			local++
+		} else if assert.True(t, ok, key) {
+			assert.Equal(t, owner, peer.name, key)
+		}
+	}
+	assert.NotZero(t, local)
+
+	// existing peers are reused
+	picker.SetWeighted(map[string]float64{"http://a": 1, "http://b": 2, "http://d": 1})
+	assert.ElementsMatch(t, []string{"http://b", "http://c", "http://d"}, created)
+	weight, _ := picker.Ring().Load().Weight("http://b")
+	assert.Equal(t, 2.0, weight)
+}
+
+func TestPickPeers(t *testing.T) {
+	picker := New("a", func(node string) string { return node })
+	picker.Set("a", "b", "c")
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key%d", i)
+		nodes, _ := picker.Ring().GetNodes(key, 2)
+		expected := make([]string, 0)
+		for _, node := range nodes {
+			if node != "a" {
+				expected = append(expected, node)
+			}
+		}
+		assert.Equal(t, expected, picker.PickPeers(key, 2), key)
+	}
+}
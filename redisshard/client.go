@@ -0,0 +1,181 @@
+// Package redisshard routes Redis commands to shards with a consistent
+// hashing ring. It is client agnostic: it maps keys to the client handles
+// of the shards, e.g. *redis.Client, and leaves issuing commands to the
+// caller.
+//
+//	shards := redisshard.New([]string{"10.0.0.1:6379", "10.0.0.2:6379"},
+//		func(addr string) *redis.Client {
+//			return redis.NewClient(&redis.Options{Addr: addr})
+//		}, hashring.WithReplicas(160))
+//	err := shards.ForEachShard(ctx, keys, func(ctx context.Context, client *redis.Client, keys []string) error {
+//		return client.MGet(ctx, keys...).Err()
+//	})
+package redisshard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/serialx/hashring"
+)
+
+var hashTag = hashring.HashTagExtractor("{}")
+
+// HashTag returns the part of key which is hashed following the Redis
+// Cluster rules: if key contains a non-empty substring between the first
+// "{" and the first "}" after it, only that substring is hashed, so
+// "{user:42}:profile" and "{user:42}:feed" land on the same shard.
+func HashTag(key string) string {
+	return hashTag(key)
+}
+
+// ShardedClient maps keys to the clients of the shards owning them.
+type ShardedClient[C any] struct {
+	ring      *hashring.AtomicRing
+	newClient func(node string) C
+
+	mu      sync.RWMutex
+	clients map[string]C
+}
+
+// New creates a sharded client for the given shards, each with weight 1.
+// newClient creates the client of a shard; it is called once per shard.
+// The ring hashes the HashTag of keys unless opts set another KeyExtractor.
+func New[C any](nodes []string, newClient func(node string) C, opts ...hashring.Option) *ShardedClient[C] {
+	opts = append([]hashring.Option{hashring.WithKeyExtractor(hashTag)}, opts...)
+	s := &ShardedClient[C]{
+		ring:      hashring.NewAtomicRing(hashring.New(nil, opts...)),
+		newClient: newClient,
+		clients:   make(map[string]C),
+	}
+	weights := make(map[string]float64, len(nodes))
+	for _, node := range nodes {
+		weights[node] = 1
+	}
+	s.SetNodes(weights)
+	return s
+}
+
+func (s *ShardedClient[C]) Ring() *hashring.AtomicRing {
+	return s.ring
+}
+
+// SetNodes replaces the shards and their weights. Clients of shards which
+// stay are reused; the removed ones are returned so they can be closed.
+func (s *ShardedClient[C]) SetNodes(weights map[string]float64) (removed []C) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clients := make(map[string]C, len(weights))
+	for node := range weights {
+		if client, ok := s.clients[node]; ok {
+			clients[node] = client
+		} else {
+			clients[node] = s.newClient(node)
+		}
+	}
+	for node, client := range s.clients {
+		if _, ok := clients[node]; !ok {
+			removed = append(removed, client)
+		}
+	}
+	s.clients = clients
+	s.ring.UpdateWithFloatWeights(weights)
+	return removed
+}
+
+// Node returns the shard which owns key.
+func (s *ShardedClient[C]) Node(key string) (node string, ok bool) {
+	return s.ring.GetNode(key)
+}
+
+// Client returns the client of the shard which owns key.
+func (s *ShardedClient[C]) Client(key string) (client C, ok bool) {
+	node, ok := s.Node(key)
+	if !ok {
+		return client, false
+	}
+	return s.client(node)
+}
+
+// GroupKeys groups keys by the shard owning them, keeping their order. It
+// looks every key up once against the same version of the ring.
+func (s *ShardedClient[C]) GroupKeys(keys []string) map[string][]string {
+	return groupKeys(s.ring.Load(), keys)
+}
+
+func groupKeys(ring *hashring.HashRing, keys []string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, key := range keys {
+		if node, ok := ring.GetNode(key); ok {
+			groups[node] = append(groups[node], key)
+		}
+	}
+	return groups
+}
+
+var (
+	// ErrNoShards is returned by ForEachShard if the ring is empty.
+	ErrNoShards = errors.New("no shards available")
+	// ErrNoClient is returned by ForEachShard for keys owned by a shard
+	// without a client, which happens if the ring was updated through
+	// Ring rather than SetNodes.
+	ErrNoClient = errors.New("shard has no client")
+)
+
+// ForEachShard groups keys by shard and calls fn concurrently for every
+// shard with its client and keys, e.g. to issue one MGET per shard. It
+// waits for all calls and returns their errors joined. Keys which can't
+// be routed to a client are reported in an error wrapping ErrNoClient.
+func (s *ShardedClient[C]) ForEachShard(
+	ctx context.Context,
+	keys []string,
+	fn func(ctx context.Context, client C, keys []string) error,
+) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	// take the ring and the clients from the same SetNodes call
+	s.mu.RLock()
+	ring := s.ring.Load()
+	clients := s.clients
+	s.mu.RUnlock()
+
+	groups := groupKeys(ring, keys)
+	if len(groups) == 0 {
+		return ErrNoShards
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 0, len(groups))
+	var errsMu sync.Mutex
+	for node, nodeKeys := range groups {
+		client, ok := clients[node]
+		if !ok {
+			errsMu.Lock()
+			errs = append(errs, fmt.Errorf("can't route keys %q to shard %q: %w", nodeKeys, node, ErrNoClient))
+			errsMu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(ctx, client, nodeKeys); err != nil {
+				errsMu.Lock()
+				errs = append(errs, err)
+				errsMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (s *ShardedClient[C]) client(node string) (client C, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	client, ok = s.clients[node]
+	return client, ok
+}
@@ -0,0 +1,154 @@
+package redisshard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/serialx/hashring"
+	"github.com/stretchr/testify/assert"
+)
+
+type testClient struct {
+	addr string
+}
+
+func TestHashTag(t *testing.T) {
+	for key, expected := range map[string]string{
+		"{user:42}:profile": "user:42",
+		"feed:{user:42}":    "user:42",
+		"{user:42}{x}":      "user:42",
+		"user:42":           "user:42",
+		"{}user:42":         "{}user:42",
+		"user:{42":          "user:{42",
+		"user:}42{":         "user:}42{",
+		"{{a}}":             "{a",
+	} {
+		assert.Equal(t, expected, HashTag(key), key)
+	}
+}
+
+func newTestClient() *ShardedClient[*testClient] {
+	return New([]string{"10.0.0.1:6379", "10.0.0.2:6379", "10.0.0.3:6379"},
+		func(addr string) *testClient { return &testClient{addr: addr} },
+		hashring.WithReplicas(40))
+}
+
+func TestShardedClient(t *testing.T) {
+	shards := newTestClient()
+
+	profile, ok := shards.Client("{user:42}:profile")
+	assert.True(t, ok)
+	feed, _ := shards.Client("{user:42}:feed")
+	assert.Same(t, profile, feed)
+	node, _ := shards.Ring().GetNode("user:42")
+	assert.Equal(t, node, profile.addr)
+
+	removed := shards.SetNodes(map[string]float64{"10.0.0.1:6379": 1, "10.0.0.2:6379": 2})
+	if assert.Len(t, removed, 1) {
+		assert.Equal(t, "10.0.0.3:6379", removed[0].addr)
+	}
+	client, _ := shards.Client("{user:42}:profile")
+	node, _ = shards.Node("{user:42}:profile")
+	assert.Equal(t, node, client.addr)
+}
+
+func TestGroupKeys(t *testing.T) {
+	shards := newTestClient()
+
+	keys := make([]string, 0)
+	for i := 0; i < 100; i++ {
+		keys = append(keys, fmt.Sprintf("{user:%d}:profile", i%30), fmt.Sprintf("key%d", i))
+	}
+
+	groups := shards.GroupKeys(keys)
+	assert.Len(t, groups, 3)
+	grouped := 0
+	for node, nodeKeys := range groups {
+		grouped += len(nodeKeys)
+		for _, key := range nodeKeys {
+			owner, _ := shards.Node(key)
+			assert.Equal(t, node, owner, key)
+		}
+	}
+	assert.Equal(t, len(keys), grouped)
+}
+
+func TestForEachShard(t *testing.T) {
+	shards := newTestClient()
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	var mu sync.Mutex
+	seen := make([]string, 0)
+	err := shards.ForEachShard(context.Background(), keys, func(ctx context.Context, client *testClient, keys []string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, key := range keys {
+			node, _ := shards.Node(key)
+			assert.Equal(t, node, client.addr)
+		}
+		seen = append(seen, keys...)
+		return nil
+	})
+	assert.NoError(t, err)
+	sort.Strings(seen)
+	assert.Equal(t, keys, seen)
+
+	failure := errors.New("shard down")
+	err = shards.ForEachShard(context.Background(), keys, func(ctx context.Context, client *testClient, keys []string) error {
+		if client.addr == "10.0.0.1:6379" {
+			return failure
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, failure)
+
+	empty := New(nil, func(addr string) *testClient { return &testClient{addr: addr} })
+	err = empty.ForEachShard(context.Background(), keys, func(context.Context, *testClient, []string) error { return nil })
+	assert.ErrorIs(t, err, ErrNoShards)
+}
+
+func TestForEachShardRouting(t *testing.T) {
+	shards := newTestClient()
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	// every call sees a ring and clients from the same SetNodes call
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			weights := map[string]float64{"10.0.0.1:6379": 1, "10.0.0.2:6379": 1}
+			if i%2 == 0 {
+				weights["10.0.0.3:6379"] = 1
+			}
+			shards.SetNodes(weights)
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		var mu sync.Mutex
+		routed := 0
+		err := shards.ForEachShard(context.Background(), keys, func(ctx context.Context, client *testClient, keys []string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			routed += len(keys)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, len(keys), routed)
+	}
+	<-done
+
+	// shards added through the ring have no client
+	shards.Ring().Update(func(ring *hashring.HashRing) *hashring.HashRing {
+		return ring.AddWeightedNode("10.0.0.9:6379", 100)
+	})
+	err := shards.ForEachShard(context.Background(), keys, func(context.Context, *testClient, []string) error { return nil })
+	assert.ErrorIs(t, err, ErrNoClient)
+	assert.ErrorContains(t, err, `to shard "10.0.0.9:6379"`)
+}
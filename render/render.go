@@ -0,0 +1,144 @@
+// Package render draws a consistent hashing ring, e.g. to review a
+// weight change:
+//
+//	render.SVG(w, ring)       // the ring as a circle of colored arcs
+//	render.DOT(w, ring)       // the virtual points as a Graphviz cycle
+//	render.ASCII(w, ring, 40) // a bar of the ownership of every node
+//
+// All of them are built on hashring.HashRing.Arcs, so they show the same
+// ownership as hashring.HashRing.Ownership.
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/serialx/hashring"
+)
+
+const (
+	size   = 400
+	radius = 160
+	stroke = 40
+)
+
+// SVG writes the ring as an SVG image. Every arc of the circle has the
+// color of the node owning it and starts at the previous virtual point,
+// clockwise from the top. A legend lists the ownership of the nodes.
+func SVG(w io.Writer, ring *hashring.HashRing) error {
+	nodes, colors := palette(ring)
+	ownership := ring.Ownership()
+	legend := 20 * len(nodes)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		size, size+legend, size, size+legend)
+	for _, arc := range ring.Arcs() {
+		title := html.EscapeString(fmt.Sprintf("%s #%d", arc.Node, arc.Pos))
+		if arc.End-arc.Start >= 1 {
+			fmt.Fprintf(bw, `<circle cx="%d" cy="%d" r="%d" fill="none" stroke="%s" stroke-width="%d"><title>%s</title></circle>`+"\n",
+				size/2, size/2, radius, colors[arc.Node], stroke, title)
+			continue
+		}
+		x1, y1 := point(arc.Start)
+		x2, y2 := point(arc.End)
+		large := 0
+		if arc.End-arc.Start > 0.5 {
+			large = 1
+		}
+		fmt.Fprintf(bw, `<path d="M %s %s A %d %d 0 %d 1 %s %s" fill="none" stroke="%s" stroke-width="%d"><title>%s</title></path>`+"\n",
+			x1, y1, radius, radius, large, x2, y2, colors[arc.Node], stroke, title)
+	}
+	for i, node := range nodes {
+		y := size + 20*i
+		fmt.Fprintf(bw, `<rect x="20" y="%d" width="12" height="12" fill="%s"/>`+"\n", y, colors[node])
+		fmt.Fprintf(bw, `<text x="40" y="%d" font-family="sans-serif" font-size="12">%s %.2f%%</text>`+"\n",
+			y+11, html.EscapeString(node), ownership[node]*100)
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// point returns the coordinates of a fraction of the hash space on the
+// circle, clockwise from the top.
+func point(fraction float64) (x, y string) {
+	angle := 2 * math.Pi * fraction
+	return coordinate(size/2 + radius*math.Sin(angle)), coordinate(size/2 - radius*math.Cos(angle))
+}
+
+func coordinate(c float64) string {
+	return strconv.FormatFloat(c, 'f', 2, 64)
+}
+
+// DOT writes the virtual points of the ring as a Graphviz graph, a cycle
+// in clockwise order with the points colored by node. It is best laid
+// out with circo.
+func DOT(w io.Writer, ring *hashring.HashRing) error {
+	_, colors := palette(ring)
+	arcs := ring.Arcs()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph ring {")
+	fmt.Fprintln(bw, "\tlayout=circo;")
+	fmt.Fprintln(bw, "\tnode [shape=circle, style=filled];")
+	for _, arc := range arcs {
+		label := fmt.Sprintf("%s\n%.2f%%", arc.Node, (arc.End-arc.Start)*100)
+		fmt.Fprintf(bw, "\tp%d [label=%s, fillcolor=%q];\n", arc.Pos, strconv.Quote(label), colors[arc.Node])
+	}
+	for i, arc := range arcs {
+		fmt.Fprintf(bw, "\tp%d -> p%d;\n", arc.Pos, arcs[(i+1)%len(arcs)].Pos)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// ASCII writes a bar of width characters per node showing its share of
+// the hash space:
+//
+//	a  ##########..........................  26.30%
+//	b  #####################...............  53.38%
+func ASCII(w io.Writer, ring *hashring.HashRing, width int) error {
+	nodes, _ := palette(ring)
+	ownership := ring.Ownership()
+	pad := 0
+	for _, node := range nodes {
+		pad = max(pad, len(node))
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, node := range nodes {
+		filled := int(math.Round(ownership[node] * float64(width)))
+		fmt.Fprintf(bw, "%-*s  %s%s  %6.2f%%\n", pad, node,
+			strings.Repeat("#", filled), strings.Repeat(".", width-filled), ownership[node]*100)
+	}
+	return bw.Flush()
+}
+
+// palette returns the sorted nodes of the ring and a color for each of
+// them, evenly spaced around the color wheel.
+func palette(ring *hashring.HashRing) (nodes []string, colors map[string]string) {
+	nodes = slices.Sorted(maps.Keys(ring.Weights()))
+	colors = make(map[string]string, len(nodes))
+	for i, node := range nodes {
+		colors[node] = hue(float64(i) / float64(len(nodes)))
+	}
+	return nodes, colors
+}
+
+// hue returns a pastel RGB color of the hue in [0, 1), which both SVG and
+// Graphviz understand.
+func hue(h float64) string {
+	const s, v = 0.55, 0.9
+	rgb := func(n float64) int {
+		k := math.Mod(n+h*6, 6)
+		return int(math.Round(255 * (v - v*s*max(0, min(k, 4-k, 1)))))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", rgb(5), rgb(3), rgb(1))
+}
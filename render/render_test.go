@@ -0,0 +1,70 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/serialx/hashring"
+	"github.com/stretchr/testify/assert"
+)
+
+func uint32Hash(key []byte) hashring.HashKey {
+	switch string(key) {
+	case "a-0":
+		return hashring.Uint32HashKey(1 << 30)
+	case "b-0":
+		return hashring.Uint32HashKey(1 << 31)
+	}
+	return hashring.Uint32HashKey(0)
+}
+
+func TestSVG(t *testing.T) {
+	ring := hashring.NewWithHash([]string{"a", "b"}, uint32Hash)
+
+	var buf bytes.Buffer
+	assert.NoError(t, SVG(&buf, ring))
+	out := buf.String()
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), new(struct{})))
+
+	// a owns 3/4 of the circle from the bottom to the right, b the rest
+	assert.Contains(t, out, `<path d="M 200.00 360.00 A 160 160 0 1 1 360.00 200.00" fill="none" stroke="#e66767"`)
+	assert.Contains(t, out, `<path d="M 360.00 200.00 A 160 160 0 0 1 200.00 360.00" fill="none" stroke="#67e6e6"`)
+	assert.Contains(t, out, ">a 75.00%</text>")
+	assert.Contains(t, out, ">b 25.00%</text>")
+
+	buf.Reset()
+	assert.NoError(t, SVG(&buf, hashring.New([]string{"<a>"})))
+	assert.Contains(t, buf.String(), `<circle cx="200" cy="200" r="160"`)
+	assert.Contains(t, buf.String(), ">&lt;a&gt; 100.00%</text>")
+}
+
+func TestDOT(t *testing.T) {
+	ring := hashring.NewWithHash([]string{"a", "b"}, uint32Hash)
+
+	var buf bytes.Buffer
+	assert.NoError(t, DOT(&buf, ring))
+	assert.Equal(t, `digraph ring {
+	layout=circo;
+	node [shape=circle, style=filled];
+	p0 [label="a\n75.00%", fillcolor="#e66767"];
+	p1 [label="b\n25.00%", fillcolor="#67e6e6"];
+	p0 -> p1;
+	p1 -> p0;
+}
+`, buf.String())
+}
+
+func TestASCII(t *testing.T) {
+	ring := hashring.NewWithHash([]string{"a", "bb"}, uint32Hash)
+	ring = ring.RemoveNode("bb").AddNode("b")
+
+	var buf bytes.Buffer
+	assert.NoError(t, ASCII(&buf, ring, 8))
+	assert.Equal(t, strings.Join([]string{
+		"a  ######..   75.00%",
+		"b  ##......   25.00%",
+		"",
+	}, "\n"), buf.String())
+}
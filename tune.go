@@ -0,0 +1,83 @@
+package hashring
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+)
+
+// ErrTolerance is returned by Tune if it can't bring the ownership of the
+// nodes within tolerance of their targets.
+var ErrTolerance = errors.New("ownership is not within tolerance")
+
+// tuneIterations is the maximum number of rings built by Tune.
+const tuneIterations = 100
+
+// Tune returns a ring with the nodes of targets and the configuration of
+// h, whose weights are adjusted so that the share of the hash space owned
+// by every node is within tolerance of its share of the targets. The
+// targets are capacity ratios, e.g. {"a": 1, "b": 2} to give b twice the
+// keys of a, and the tolerance is relative, e.g. 0.05 for 5%.
+//
+// Starting with the targets, Tune corrects the weights by the ratio of the
+// target to the real share of every node until the shares are within
+// tolerance. Rounding weights to virtual points limits how close the
+// shares can get, so use it together with WithReplicas. If the shares
+// don't get within tolerance, Tune returns the closest ring it found along
+// with an error wrapping ErrTolerance.
+func (h *HashRing) Tune(targets map[string]float64, tolerance float64) (*HashRing, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("can't tune a ring without targets")
+	}
+	total := 0.0
+	nodes := make([]string, 0, len(targets))
+	for node, target := range targets {
+		if !(target > 0) || math.IsInf(target, 0) {
+			return nil, fmt.Errorf("node %q has target %v", node, target)
+		}
+		total += target
+		nodes = append(nodes, node)
+	}
+
+	weights := maps.Clone(targets)
+	var best *HashRing
+	bestDeviation := math.Inf(1)
+	for range tuneIterations {
+		ring := h.rebuild(nodes, maps.Clone(weights))
+		ownership := ring.Ownership()
+
+		deviation := 0.0
+		for node, target := range targets {
+			share := target / total
+			deviation = max(deviation, math.Abs(ownership[node]-share)/share)
+		}
+		if deviation < bestDeviation {
+			best, bestDeviation = ring, deviation
+		}
+		if deviation <= tolerance {
+			break
+		}
+
+		// keep the sum of the weights, so the number of points stays the
+		// same as for the targets
+		sum := 0.0
+		for node, target := range targets {
+			if ownership[node] > 0 {
+				weights[node] *= target / total / ownership[node]
+			}
+			sum += weights[node]
+		}
+		for node := range weights {
+			weights[node] *= total / sum
+		}
+	}
+
+	if h.observer != nil {
+		h.observer.OnRebuild(h, best)
+	}
+	if bestDeviation > tolerance {
+		return best, fmt.Errorf("%w: a share is off by %.1f%%", ErrTolerance, bestDeviation*100)
+	}
+	return best, nil
+}
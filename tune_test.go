@@ -0,0 +1,66 @@
+package hashring
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func maxDeviation(ownership, targets map[string]float64) float64 {
+	total := 0.0
+	for _, target := range targets {
+		total += target
+	}
+	deviation := 0.0
+	for node, target := range targets {
+		share := target / total
+		deviation = max(deviation, math.Abs(ownership[node]-share)/share)
+	}
+	return deviation
+}
+
+func TestTune(t *testing.T) {
+	targets := map[string]float64{"a": 1, "b": 2, "c": 1, "d": 3}
+	ring := New([]string{"x"}, WithReplicas(100))
+
+	tuned, err := ring.Tune(targets, 0.01)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, maxDeviation(tuned.Ownership(), targets), 0.01)
+	assert.Greater(t, maxDeviation(NewWithFloatWeights(targets, WithReplicas(100)).Ownership(), targets), 0.01)
+
+	assert.Equal(t, 4, tuned.Size())
+	assert.Equal(t, 100, tuned.replicas)
+	weights := tuned.Weights()
+	assert.InDelta(t, 7, weights["a"]+weights["b"]+weights["c"]+weights["d"], 1e-9)
+	assert.Equal(t, []string{"x"}, ring.nodes)
+}
+
+func TestTuneTolerance(t *testing.T) {
+	targets := map[string]float64{"a": 1, "b": 2, "c": 1, "d": 3}
+	ring := New(nil)
+
+	tuned, err := ring.Tune(targets, 0.01)
+	assert.ErrorIs(t, err, ErrTolerance)
+	assert.Equal(t, 4, tuned.Size())
+	assert.LessOrEqual(t,
+		maxDeviation(tuned.Ownership(), targets),
+		maxDeviation(NewWithFloatWeights(targets).Ownership(), targets))
+}
+
+func TestTuneInvalid(t *testing.T) {
+	ring := New(nil)
+	_, err := ring.Tune(nil, 0.01)
+	assert.Error(t, err)
+	_, err = ring.Tune(map[string]float64{"a": 1, "b": 0}, 0.01)
+	assert.EqualError(t, err, `node "b" has target 0`)
+}
+
+func TestTuneObserver(t *testing.T) {
+	r := &recorder{}
+	ring := New([]string{"a"}, WithReplicas(100), WithObserver(r))
+
+	tuned, err := ring.Tune(map[string]float64{"a": 1, "b": 2}, 0.05)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]*HashRing{{ring, tuned}}, r.rebuilds)
+}